@@ -0,0 +1,245 @@
+// Package bot contains a player that plays tetris on its own. It looks
+// for every placement the current tetromino can reach and picks the one
+// that leaves the best stack, according to the heuristics described in:
+//   - https://codemyroad.wordpress.com/2013/04/14/tetris-ai-the-near-perfect-player/
+package bot
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"tetris/tetris"
+)
+
+// Weights are how much each feature of a stack counts towards its score.
+// Features that make the stack worse have negative weights.
+type Weights struct {
+	// Height is the sum of the heights of every column.
+	Height float64
+	// Lines are the lines cleared by the placement.
+	Lines float64
+	// Holes are the empty cells with a filled cell above them.
+	Holes float64
+	// Bumpiness is the sum of the height differences of adjacent columns.
+	Bumpiness float64
+}
+
+// DefaultWeights are the weights tuned by the article the heuristics
+// come from.
+var DefaultWeights = Weights{
+	Height:    -0.510066,
+	Lines:     0.760666,
+	Holes:     -0.35663,
+	Bumpiness: -0.184483,
+}
+
+// moves are the actions used to look for placements. The bot doesn't
+// hold, and DropDown locks the tetromino once it's placed.
+var moves = []tetris.Action{
+	tetris.MoveLeft,
+	tetris.MoveRight,
+	tetris.RotateRight,
+	tetris.RotateLeft,
+	tetris.MoveDown,
+}
+
+// Placement is where a tetromino can lock and the actions that take it
+// there from where it is, DropDown included.
+type Placement struct {
+	Tetromino *tetris.Tetromino
+	Actions   []tetris.Action
+	Score     float64
+}
+
+// Bot picks the best placement of a tetromino.
+type Bot struct {
+	weights Weights
+}
+
+// New creates a bot that scores stacks with the weights w.
+func New(w Weights) *Bot {
+	return &Bot{weights: w}
+}
+
+// Plan returns the actions that lock the current tetromino of t in the
+// best placement, nil if there's no tetromino to place.
+func (b *Bot) Plan(t *tetris.Tetris) []tetris.Action {
+	p := b.Placements(t)
+	if len(p) == 0 {
+		return nil
+	}
+	return best(p).Actions
+}
+
+func best(p []Placement) Placement {
+	// best() returns the placement with the highest score of p, which
+	// can't be empty.
+	b := p[0]
+	for _, pl := range p[1:] {
+		if pl.Score > b.Score {
+			b = pl
+		}
+	}
+	return b
+}
+
+// Placements returns every placement the current tetromino of t can
+// reach, with the shortest actions to reach it, scored by the bot.
+func (b *Bot) Placements(t *tetris.Tetris) []Placement {
+	if t == nil || t.Tetromino == nil {
+		return nil
+	}
+	type node struct {
+		tetromino *tetris.Tetromino
+		actions   []tetris.Action
+	}
+	// a breadth-first search finds the shortest actions to reach
+	// every position of the tetromino.
+	start := land(t, t.Tetromino)
+	seen := map[string]bool{key(start): true}
+	queue := []node{{tetromino: start}}
+	placed := map[string]bool{}
+	var placements []Placement
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if _, ok := t.Try(n.tetromino, tetris.MoveDown); !ok {
+			// it's resting on the stack, so it can lock here. different
+			// rotations can take the same cells, which is the same
+			// placement.
+			if c := cells(n.tetromino); !placed[c] {
+				placed[c] = true
+				placements = append(placements, Placement{
+					Tetromino: n.tetromino,
+					Actions:   dropDown(n.actions),
+					Score:     b.score(t.Stack, n.tetromino),
+				})
+			}
+		}
+		for _, a := range moves {
+			tm, ok := try(t, n.tetromino, a)
+			if !ok || seen[key(tm)] {
+				continue
+			}
+			seen[key(tm)] = true
+			queue = append(queue, node{tetromino: tm, actions: append(slices.Clip(n.actions), a)})
+		}
+	}
+	return placements
+}
+
+func try(t *tetris.Tetris, tm *tetris.Tetromino, a tetris.Action) (*tetris.Tetromino, bool) {
+	// try() is Tetris.Try with gravity at 20G, where the tetromino lands
+	// after every move.
+	tm, ok := t.Try(tm, a)
+	if ok {
+		tm = land(t, tm)
+	}
+	return tm, ok
+}
+
+func land(t *tetris.Tetris, tm *tetris.Tetromino) *tetris.Tetromino {
+	// land() returns the tetromino where it lands at 20G, or as it is
+	// below it.
+	if !t.MaxGravity() || tm.Y == tm.GhostY {
+		return tm
+	}
+	l := *tm
+	l.Y = l.GhostY
+	return &l
+}
+
+func dropDown(actions []tetris.Action) []tetris.Action {
+	// dropDown() locks the tetromino with a DropDown, which replaces the
+	// moves down at the end of the actions.
+	i := len(actions)
+	for i > 0 && actions[i-1] == tetris.MoveDown {
+		i--
+	}
+	return append(slices.Clip(actions[:i]), tetris.DropDown)
+}
+
+func (b *Bot) score(stack [][]tetris.Shape, tm *tetris.Tetromino) float64 {
+	// score() scores the stack left by locking the tetromino.
+	filled := make([][]bool, len(stack))
+	for y := range stack {
+		filled[y] = make([]bool, len(stack[y]))
+		for x := range stack[y] {
+			filled[y][x] = stack[y][x] != ""
+		}
+	}
+	for iy, row := range tm.Grid {
+		for ix, c := range row {
+			if c {
+				filled[tm.Y-iy][tm.X+ix] = true
+			}
+		}
+	}
+	var lines int
+	for y := len(filled) - 1; y >= 0; y-- {
+		if !slices.Contains(filled[y], false) {
+			filled = slices.Delete(filled, y, y+1)
+			lines++
+		}
+	}
+
+	var height, holes, bumpiness int
+	previous := -1
+	for x := range stack[0] {
+		h := 0
+		for y := len(filled) - 1; y >= 0; y-- {
+			if filled[y][x] {
+				h = y + 1
+				break
+			}
+		}
+		for y := range h {
+			if !filled[y][x] {
+				holes++
+			}
+		}
+		if previous >= 0 {
+			bumpiness += abs(h - previous)
+		}
+		height += h
+		previous = h
+	}
+	w := b.weights
+	return w.Height*float64(height) + w.Lines*float64(lines) + w.Holes*float64(holes) + w.Bumpiness*float64(bumpiness)
+}
+
+func key(tm *tetris.Tetromino) string {
+	// key() identifies the position and rotation of a tetromino.
+	var b strings.Builder
+	b.WriteString(cells(tm))
+	for _, row := range tm.Grid {
+		for _, c := range row {
+			if c {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+	}
+	return b.String()
+}
+
+func cells(tm *tetris.Tetromino) string {
+	// cells() identifies the cells of the stack a tetromino takes.
+	var b strings.Builder
+	for iy, row := range tm.Grid {
+		for ix, c := range row {
+			if c {
+				b.WriteString(strconv.Itoa(tm.X+ix) + "," + strconv.Itoa(tm.Y-iy) + ";")
+			}
+		}
+	}
+	return b.String()
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
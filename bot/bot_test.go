@@ -0,0 +1,178 @@
+package bot_test
+
+import (
+	"context"
+	"testing"
+	"tetris/bot"
+	"tetris/tetris"
+	"time"
+)
+
+func TestPlacements(t *testing.T) {
+	tests := []struct {
+		shape tetris.Shape
+		want  int
+	}{
+		{shape: tetris.O, want: 9},
+		{shape: tetris.I, want: 17},
+		{shape: tetris.T, want: 34},
+	}
+	b := bot.New(bot.DefaultWeights)
+	for _, tt := range tests {
+		t.Run(string(tt.shape), func(t *testing.T) {
+			te := tetris.NewTestTetris(tt.shape)
+			if got := len(b.Placements(te)); got != tt.want {
+				t.Errorf("wanted %d placements on an empty stack, got %d", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("20G", func(t *testing.T) {
+		// the T lands after every move, so it can't be moved over the
+		// column in the middle, which leaves it the placements on its
+		// side of the stack.
+		te := tetris.NewTestTetris(tetris.T)
+		te.Level = 19
+		for y := range 10 {
+			te.Stack[y][7] = tetris.J
+		}
+		placements := b.Placements(te)
+		te.Tetromino.Y = te.Tetromino.GhostY
+		for _, pl := range placements {
+			tm := te.Tetromino
+			for _, a := range pl.Actions {
+				if a == tetris.DropDown {
+					break
+				}
+				tm, _ = te.Try(tm, a)
+				tm.Y = tm.GhostY
+			}
+			if tm.X != pl.Tetromino.X || tm.Y != pl.Tetromino.Y {
+				t.Errorf("wanted the actions to take the T to X %d Y %d at 20G, got X %d Y %d", pl.Tetromino.X, pl.Tetromino.Y, tm.X, tm.Y)
+			}
+			for _, row := range pl.Tetromino.Grid {
+				for ix, c := range row {
+					if c && pl.Tetromino.X+ix > 7 {
+						t.Fatalf("wanted the T to stay left of the column, got it on X %d", pl.Tetromino.X)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("no tetromino", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.I)
+		te.Tetromino = nil
+		if p := b.Plan(te); p != nil {
+			t.Errorf("wanted no plan without a tetromino, got %v", p)
+		}
+	})
+}
+
+func TestPlan(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.I)
+	// row 0 has a gap an I lying flat fills.
+	for x := range 10 {
+		if x < 3 || x > 6 {
+			te.Stack[0][x] = tetris.J
+		}
+	}
+	tm := te.Tetromino
+	for _, a := range bot.New(bot.DefaultWeights).Plan(te) {
+		if a == tetris.DropDown {
+			tm.Y = tm.GhostY
+			break
+		}
+		tm, _ = te.Try(tm, a)
+	}
+	// the I lies flat in the gap, which clears the line.
+	for iy, row := range tm.Grid {
+		for ix, c := range row {
+			if c && (tm.Y-iy != 0 || tm.X+ix < 3 || tm.X+ix > 6) {
+				t.Fatalf("wanted the I to fill the gap in row 0, got it on X %d Y %d", tm.X, tm.Y)
+			}
+		}
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	e := tetris.NewEngine(tetris.WithSeed(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var p *bot.Player
+	p = bot.NewPlayer(bot.New(bot.DefaultWeights), func(a tetris.Action) {
+		e.Step(a)
+		for e.Snapshot().LinesCleared != nil {
+			e.Tick()
+		}
+		// the player stops once it has placed enough tetrominoes.
+		if e.Snapshot().Pieces >= 100 {
+			cancel()
+		}
+		p.Update(e.Snapshot())
+	}, bot.Difficulty{})
+	p.Update(e.Snapshot())
+	p.Run(ctx)
+
+	s := e.Snapshot()
+	if s.GameOver {
+		t.Errorf("wanted the bot to survive 100 tetrominoes, it topped out after %d", s.Pieces)
+	}
+	if s.LinesClear == 0 {
+		t.Errorf("wanted the bot to clear lines")
+	}
+}
+
+func TestPlayerGravity(t *testing.T) {
+	// the tetromino falls a row after every action, away from where the
+	// actions planned take it, so the player plans them again.
+	e := tetris.NewEngine(tetris.WithSeed(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var p *bot.Player
+	p = bot.NewPlayer(bot.New(bot.DefaultWeights), func(a tetris.Action) {
+		e.Step(a)
+		// it falls without locking, the player drops it.
+		if tm := e.Snapshot().Tetromino; tm != nil && tm.Y > tm.GhostY {
+			e.Tick()
+		}
+		for e.Snapshot().LinesCleared != nil {
+			e.Tick()
+		}
+		if e.Snapshot().Pieces >= 100 {
+			cancel()
+		}
+		p.Update(e.Snapshot())
+	}, bot.Difficulty{})
+	p.Update(e.Snapshot())
+	p.Run(ctx)
+
+	s := e.Snapshot()
+	if s.GameOver {
+		t.Errorf("wanted the bot to survive 100 tetrominoes, it topped out after %d", s.Pieces)
+	}
+	if s.LinesClear == 0 {
+		t.Errorf("wanted the bot to clear lines")
+	}
+}
+
+func TestPlayerMistakes(t *testing.T) {
+	// a player that always makes mistakes tops out sooner than later.
+	e := tetris.NewEngine(tetris.WithSeed(1))
+	var p *bot.Player
+	p = bot.NewPlayer(bot.New(bot.DefaultWeights), func(a tetris.Action) {
+		e.Step(a)
+		for e.Snapshot().LinesCleared != nil {
+			e.Tick()
+		}
+		p.Update(e.Snapshot())
+	}, bot.Difficulty{Mistakes: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p.Update(e.Snapshot())
+	p.Run(ctx)
+
+	if s := e.Snapshot(); !s.GameOver {
+		t.Errorf("wanted the player to top out, got %d tetrominoes placed", s.Pieces)
+	}
+}
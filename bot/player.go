@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"math/rand/v2"
+	"tetris/tetris"
+	"time"
+)
+
+// Difficulty is how well a Player plays.
+type Difficulty struct {
+	Name string
+	// Think is how long the player waits before every action.
+	Think time.Duration
+	// Mistakes is the probability, from 0 to 1, of the player placing a
+	// tetromino anywhere it can instead of in the best placement.
+	Mistakes float64
+}
+
+// The difficulties of a bot playing against a person.
+var (
+	Easy   = Difficulty{Name: "easy", Think: 400 * time.Millisecond, Mistakes: 0.2}
+	Medium = Difficulty{Name: "medium", Think: 200 * time.Millisecond, Mistakes: 0.05}
+	Hard   = Difficulty{Name: "hard", Think: 100 * time.Millisecond}
+)
+
+// Player plays a game with a Bot at a Difficulty.
+type Player struct {
+	bot        *Bot
+	act        func(tetris.Action)
+	difficulty Difficulty
+	updateCh   chan *tetris.Tetris
+}
+
+// NewPlayer creates a player that makes its actions with act, which is
+// usually the Action method of the game it plays.
+func NewPlayer(b *Bot, act func(tetris.Action), d Difficulty) *Player {
+	return &Player{
+		bot:        b,
+		act:        act,
+		difficulty: d,
+		updateCh:   make(chan *tetris.Tetris, 1),
+	}
+}
+
+// Update gives the player the latest state of the game. It doesn't
+// block, an update the player hasn't looked at yet is replaced.
+func (p *Player) Update(t *tetris.Tetris) {
+	for {
+		select {
+		case p.updateCh <- t:
+			return
+		default:
+		}
+		select {
+		case <-p.updateCh:
+		default:
+		}
+	}
+}
+
+// Run plays the game until it's over or ctx is done.
+func (p *Player) Run(ctx context.Context) {
+	c := course{piece: -1}
+	for {
+		if len(c.actions) == 0 {
+			select {
+			case t := <-p.updateCh:
+				if t.GameOver {
+					return
+				}
+				p.steer(&c, t)
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case <-time.After(p.difficulty.Think):
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case t := <-p.updateCh:
+			if t.GameOver {
+				return
+			}
+			p.steer(&c, t)
+			if len(c.actions) == 0 {
+				continue
+			}
+		default:
+		}
+		c.act(p.act)
+	}
+}
+
+// course is how the player takes a tetromino to its placement: the
+// actions left and the positions the ones made took it to.
+type course struct {
+	// piece is the number of the tetromino placed, see Tetris.Pieces,
+	// and target the cells of its placement.
+	piece   int
+	target  string
+	actions []tetris.Action
+	// state is the game the actions were planned from, and at where
+	// they have taken the tetromino so far. reached are the positions
+	// it has been in on the way.
+	state   *tetris.Tetris
+	at      *tetris.Tetromino
+	reached map[string]bool
+	// dropped is true once the tetromino has been dropped.
+	dropped bool
+}
+
+func (c *course) set(t *tetris.Tetris, pl Placement) {
+	c.piece = t.Pieces
+	c.target = cells(pl.Tetromino)
+	c.actions = pl.Actions
+	c.state = t
+	c.at = land(t, t.Tetromino)
+	c.reached = map[string]bool{key(t.Tetromino): true, key(c.at): true}
+	c.dropped = false
+}
+
+func (c *course) act(act func(tetris.Action)) {
+	// act() makes the next action, keeping track of where it takes the
+	// tetromino to.
+	a := c.actions[0]
+	c.actions = c.actions[1:]
+	if tm, ok := try(c.state, c.at, a); ok {
+		c.at = tm
+		c.reached[key(tm)] = true
+	}
+	c.dropped = a == tetris.DropDown
+	act(a)
+}
+
+func (p *Player) steer(c *course, t *tetris.Tetris) {
+	// steer() plans the actions to place a tetromino when it's first
+	// seen, and plans them again to the same placement when it's no
+	// longer where the actions made took it, like when gravity pulls it
+	// down or it lands as soon as it spawns at 20G. the positions it has
+	// been in are fine, as updates might arrive after the actions were
+	// made. for the same reason, updates of tetrominoes already dropped
+	// are skipped. a paused game gets no actions until it's resumed.
+	switch {
+	case t.Tetromino == nil || t.Pieces < c.piece:
+	case t.Paused:
+		c.actions, c.piece = nil, -1
+	case t.Pieces > c.piece:
+		if pl, ok := p.plan(t); ok {
+			c.set(t, pl)
+		}
+	case !c.dropped && !c.reached[key(t.Tetromino)]:
+		for _, pl := range p.bot.Placements(t) {
+			if cells(pl.Tetromino) == c.target {
+				c.set(t, pl)
+				return
+			}
+		}
+		// the placement is out of reach now, so there's a new one.
+		if pl, ok := p.plan(t); ok {
+			c.set(t, pl)
+		}
+	}
+}
+
+func (p *Player) plan(t *tetris.Tetris) (Placement, bool) {
+	// plan() returns the best placement, or any placement when the
+	// player makes a mistake. it's false when there's none.
+	pl := p.bot.Placements(t)
+	if len(pl) == 0 {
+		return Placement{}, false
+	}
+	if p.difficulty.Mistakes > 0 && rand.Float64() < p.difficulty.Mistakes { //nolint: gosec
+		return pl[rand.IntN(len(pl))], true //nolint: gosec
+	}
+	return best(pl), true
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"sync"
+	"sync/atomic"
+	"tetris/message"
+	"tetris/pb"
+	"tetris/tetris"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// serverGame is the game of the player in online matches the server has
+// the authority of. the server runs it from the actions of the player,
+// and its updates are the states the server sends back.
+type serverGame struct {
+	updateCh chan *tetris.Tetris
+	// actions are the messages with the actions of the player to send to
+	// the server.
+	actions chan *pb.GameMessage
+	target  *atomic.Int32
+	done    chan struct{}
+	stop    sync.Once
+	// handlers get the events of the game, which the server doesn't send,
+	// so they're told from the states it does. last is the last state.
+	handlers []tetris.EventHandler
+	last     *tetris.Tetris
+}
+
+func newServerGame(target *atomic.Int32) *serverGame {
+	return &serverGame{
+		updateCh: make(chan *tetris.Tetris),
+		actions:  make(chan *pb.GameMessage),
+		target:   target,
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *serverGame) Start(...tetris.Option)           {}
+func (s *serverGame) GetUpdate() <-chan *tetris.Tetris { return s.updateCh }
+func (s *serverGame) Pause()                           {}
+func (s *serverGame) Resume()                          {}
+func (s *serverGame) RemoteGarbage(int32)              {}
+func (s *serverGame) Replay() *tetris.Replay           { return nil }
+
+// Subscribe calls h with the events of the game, which must be called
+// before the first state is sent.
+func (s *serverGame) Subscribe(h tetris.EventHandler) {
+	s.handlers = append(s.handlers, h)
+}
+
+// Action sends the action to the server with the current target, so a
+// new target is taken into account from the next action on.
+func (s *serverGame) Action(a tetris.Action) {
+	m := pb.GameMessage_builder{
+		Action: proto.String(string(a)),
+		Target: pb.Target(s.target.Load()).Enum(),
+	}.Build()
+	select {
+	case s.actions <- m:
+	case <-s.done:
+	}
+}
+
+func (s *serverGame) Stop() {
+	s.stop.Do(func() { close(s.done) })
+}
+
+func (s *serverGame) update(gm *pb.GameMessage) {
+	// update() passes on the state of the game sent by the server.
+	t := message.Game(gm.GetState())
+	s.emit(t)
+	select {
+	case s.updateCh <- t:
+	case <-s.done:
+	}
+}
+
+func (s *serverGame) emit(t *tetris.Tetris) {
+	// emit() sends the events of the game between the last state and t,
+	// as the game the server runs did before sending t.
+	var events []tetris.Event
+	if t.LinesCleared != nil && (s.last == nil || s.last.LinesCleared == nil) {
+		events = append(events, t.LinesCleared)
+	}
+	if s.last != nil && t.Level > s.last.Level {
+		events = append(events, tetris.LevelUp{Level: t.Level})
+	}
+	if t.GameOver {
+		events = append(events, tetris.GameOver{Won: t.Won, Score: t.Score})
+	}
+	s.last = t
+	for _, e := range events {
+		for _, h := range s.handlers {
+			h(e)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"tetris/message"
+	"tetris/pb"
+	"tetris/tetris"
+	"time"
+)
+
+func TestServerGame(t *testing.T) {
+	var target atomic.Int32
+	target.Store(int32(pb.Target_TARGET_KO))
+	sg := newServerGame(&target)
+
+	// actions are sent to the server with the target.
+	go sg.Action(tetris.MoveLeft)
+	if gm := <-sg.actions; gm.GetAction() != string(tetris.MoveLeft) || gm.GetTarget() != pb.Target_TARGET_KO {
+		t.Errorf("wanted a move left targeting KO, got %v", gm)
+	}
+
+	// the states sent by the server are the updates of the game.
+	state := message.State(tetris.NewTestTetris(tetris.J))
+	go sg.update(pb.GameMessage_builder{State: state}.Build())
+	if u := <-sg.GetUpdate(); u.Tetromino.Shape != tetris.J || len(u.Stack) != 20 {
+		t.Errorf("wanted the game sent by the server, got %+v", u)
+	}
+
+	// actions don't wait once the game is stopped.
+	sg.Stop()
+	done := make(chan struct{})
+	go func() { sg.Action(tetris.DropDown); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("wanted the action to be dropped once the game is stopped")
+	}
+}
+
+func TestServerGameEvents(t *testing.T) {
+	sg := newServerGame(&atomic.Int32{})
+	var events []tetris.Event
+	sg.Subscribe(func(e tetris.Event) { events = append(events, e) })
+	go func() {
+		for range sg.GetUpdate() { // nolint:revive
+		}
+	}()
+	defer sg.Stop()
+
+	// the events are told from the changes between the states sent.
+	states := []*tetris.Tetris{
+		{Level: 1},
+		{Level: 1, LinesCleared: &tetris.LinesCleared{Rows: []int{0}}},
+		{Level: 2},
+		{Level: 2, GameOver: true, Score: 300},
+	}
+	for _, s := range states {
+		sg.update(pb.GameMessage_builder{State: message.State(s)}.Build())
+	}
+	want := []tetris.Event{
+		&tetris.LinesCleared{Rows: []int{0}},
+		tetris.LevelUp{Level: 2},
+		tetris.GameOver{Score: 300},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("wanted events %v, got %v", want, events)
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"maps"
+	"slices"
+	"tetris/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// targetNames are the names of the garbage targets shown in matches of
+// more than two players.
+var targetNames = map[pb.Target]string{
+	pb.Target_TARGET_RANDOM:    "random",
+	pb.Target_TARGET_ATTACKERS: "attackers",
+	pb.Target_TARGET_KO:        "KO",
+}
+
+// battle is the state of the opponents of an online match.
+type battle struct {
+	// players is the number of players the match started with.
+	players   int
+	opponents map[int32]*pb.GameMessage
+	// watching is set for spectators, whose opponents are all the players.
+	watching bool
+}
+
+func newBattle(players int) *battle {
+	return &battle{
+		players:   max(players, 2),
+		opponents: make(map[int32]*pb.GameMessage),
+	}
+}
+
+func (b *battle) update(gm *pb.GameMessage) {
+	// update() records the last message of an opponent. the message of an
+	// opponent leaving has no stack, so the last one is kept.
+	if prev, ok := b.opponents[gm.GetPlayer()]; ok && gm.GetLeft() {
+		prev = proto.CloneOf(prev)
+		prev.SetIsGameOver(true)
+		prev.SetLeft(true)
+		gm = prev
+	}
+	b.opponents[gm.GetPlayer()] = gm
+}
+
+func (b *battle) playing() int {
+	// playing() returns how many opponents are still playing, including
+	// the ones that haven't sent anything yet.
+	n := b.players
+	if !b.watching {
+		n--
+	}
+	for _, gm := range b.opponents {
+		if gm.GetIsGameOver() {
+			n--
+		}
+	}
+	return n
+}
+
+func (b *battle) winner() string {
+	for _, gm := range b.opponents {
+		if !gm.GetIsGameOver() {
+			return gm.GetName()
+		}
+	}
+	return ""
+}
+
+func (b *battle) list() []*pb.GameMessage {
+	// list() returns the opponents by seat.
+	l := make([]*pb.GameMessage, 0, len(b.opponents))
+	for _, p := range slices.Sorted(maps.Keys(b.opponents)) {
+		l = append(l, b.opponents[p])
+	}
+	return l
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"testing"
+	"tetris/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBattle(t *testing.T) {
+	b := newBattle(4)
+	if b.playing() != 3 {
+		t.Errorf("wanted 3 opponents playing before any message, got %d", b.playing())
+	}
+	b.update(pb.GameMessage_builder{Player: proto.Int32(3), Name: proto.String("p3"), Score: proto.Int32(10)}.Build())
+	b.update(pb.GameMessage_builder{Player: proto.Int32(2), Name: proto.String("p2"), IsGameOver: proto.Bool(true)}.Build())
+	b.update(pb.GameMessage_builder{Player: proto.Int32(4), Name: proto.String("p4")}.Build())
+	b.update(pb.GameMessage_builder{Player: proto.Int32(3), Name: proto.String("p3"), IsGameOver: proto.Bool(true), Left: proto.Bool(true)}.Build())
+
+	l := b.list()
+	if len(l) != 3 || l[0].GetPlayer() != 2 || l[1].GetPlayer() != 3 || l[2].GetPlayer() != 4 {
+		t.Fatalf("wanted the opponents by seat, got %v", l)
+	}
+	if l[1].GetScore() != 10 || !l[1].GetIsGameOver() || !l[1].GetLeft() {
+		t.Errorf("wanted the last state of the opponent that left, got %v", l[1])
+	}
+	if b.playing() != 1 || b.winner() != "p4" {
+		t.Errorf("wanted p4 as the only opponent playing, got %d playing and %q", b.playing(), b.winner())
+	}
+}
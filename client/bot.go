@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"tetris/bot"
+	"tetris/message"
+	"tetris/pb"
+	"tetris/tetris"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// botDemoDifficulty is how the bot of the demo plays, slow enough to be
+// watched.
+var botDemoDifficulty = bot.Hard
+
+// botPlayer is a bot playing a game of the client.
+type botPlayer struct {
+	*bot.Player
+	game   tetrisGame
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newBotPlayer(g tetrisGame, d bot.Difficulty) *botPlayer {
+	// newBotPlayer() starts a bot that plays g, which must be started
+	// separately.
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &botPlayer{
+		Player: bot.NewPlayer(bot.New(bot.DefaultWeights), g.Action, d),
+		game:   g,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(b.done)
+		b.Run(ctx)
+	}()
+	return b
+}
+
+func (b *botPlayer) stop() {
+	// stop() stops the bot and then its game. the game goes on until the
+	// bot is done with its last action, which would otherwise wait for
+	// a stopped game.
+	b.cancel()
+	for {
+		select {
+		case <-b.done:
+			b.game.Stop()
+			return
+		case <-b.game.GetUpdate():
+		}
+	}
+}
+
+func (c *Client) listenBot(ctx context.Context) {
+	// listenBot() lets the bot play a marathon game for the player to
+	// watch, until it tops out or the player quits.
+	b := newBotPlayer(c.tetris, botDemoDifficulty)
+	defer b.cancel()
+	c.events.pop()
+	go c.tetris.Start(tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(c.level), tetris.WithGoal(c.goal))
+	c.render.status(botDemo())
+	for {
+		select {
+		case u := <-c.tetris.GetUpdate():
+			evs := c.events.pop()
+			c.render.singlePlayer(u)
+			b.Update(u)
+			c.handleEvents(evs)
+			if u.GameOver {
+				b.stop()
+				c.state.set(lobby)
+				c.render.status(noStatus())
+				c.render.lobby(botFinished(u.Score))
+				return
+			}
+		case <-ctx.Done():
+			b.stop()
+			c.state.set(lobby)
+			c.render.status(noStatus())
+			c.render.lobby(defaultLobby())
+			return
+		}
+	}
+}
+
+func (c *Client) listenCPU(d bot.Difficulty) {
+	// listenCPU() plays a multiplayer game against the bot, which plays
+	// its own game with the same tetrominoes. each side sends its
+	// garbage to the other like in online games.
+	c.events.pop()
+	go c.tetris.Start(tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(c.level), tetris.WithGoal(c.goal), tetris.WithLevelCap(0))
+	lu := <-c.tetris.GetUpdate()
+	b := newBotPlayer(c.cpu, d)
+	defer b.cancel()
+	go c.cpu.Start(tetris.WithSeed(lu.Seed), tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(c.level), tetris.WithGoal(c.goal), tetris.WithLevelCap(0))
+	name := "CPU " + d.Name
+	c.render.multiPlayer(&mpData{local: lu, remote: pb.GameMessage_builder{Name: proto.String(name)}.Build()})
+	for {
+		select {
+		case lu := <-c.tetris.GetUpdate():
+			evs := c.events.pop()
+			c.cpu.RemoteGarbage(int32(lu.GarbageSent)) // nolint:gosec
+			c.render.multiPlayer(&mpData{local: lu})
+			c.handleEvents(evs)
+			if lu.GameOver {
+				b.stop()
+				c.tetris.Stop()
+				c.saveReplay()
+				c.state.set(lobby)
+				c.render.lobby(gameOver())
+				return
+			}
+		case ru := <-c.cpu.GetUpdate():
+			b.Update(ru)
+			c.tetris.RemoteGarbage(int32(ru.GarbageSent)) // nolint:gosec
+			c.render.multiPlayer(&mpData{remote: message.FromGame(name, ru)})
+			if ru.GameOver {
+				b.stop()
+				c.tetris.Stop()
+				c.saveReplay()
+				c.state.set(lobby)
+				c.render.lobby(youWon())
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"tetris/bot"
+	"tetris/tetris"
+	"time"
+)
+
+func TestListenBot(t *testing.T) {
+	render := &mockRender{}
+	cl := &Client{
+		tetris: tetris.NewGame(tetris.WithSeed(1), tetris.WithTicker(&replayTicker{ch: make(chan time.Time)})),
+		render: render,
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		state:  &state{current: watchingBot},
+		level:  1,
+		goal:   tetris.FixedGoal,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { cl.listenBot(ctx); close(done) }()
+
+	// a tetromino takes the bot a few actions to place.
+	time.Sleep(12 * botDemoDifficulty.Think)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the bot demo to end")
+	}
+	if render.local == nil || render.local.Pieces == 0 {
+		t.Errorf("wanted the bot to place tetrominoes")
+	}
+	if cl.state.get() != lobby || render.lobbyCount != 1 {
+		t.Errorf("wanted to be back in the lobby after quitting the bot demo")
+	}
+}
+
+func TestListenCPU(t *testing.T) {
+	newClient := func() (*Client, *mockRender) {
+		render := &mockRender{}
+		cl := &Client{
+			tetris: tetris.NewGame(tetris.WithTicker(&replayTicker{ch: make(chan time.Time)})),
+			// the cpu game needs its ticker to get past the line clear delay.
+			cpu:    tetris.NewGame(),
+			render: render,
+			logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			state:  &state{current: playingCPU},
+			level:  1,
+			goal:   tetris.FixedGoal,
+		}
+		cl.tetris.Subscribe(cl.events.push)
+		return cl, render
+	}
+	wait := func(t *testing.T, done chan struct{}) {
+		t.Helper()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the game against the cpu to end")
+		}
+	}
+
+	t.Run("the player tops out", func(t *testing.T) {
+		cl, render := newClient()
+		done := make(chan struct{})
+		go func() { cl.listenCPU(bot.Hard); close(done) }()
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					cl.tetris.Action(tetris.DropDown)
+				}
+			}
+		}()
+		wait(t, done)
+		if cl.state.get() != lobby || render.lobbyCount != 1 || render.multiPlayerCount == 0 {
+			t.Errorf("wanted the game rendered and back in the lobby after topping out")
+		}
+	})
+
+	t.Run("the cpu tops out", func(t *testing.T) {
+		cl, render := newClient()
+		dir := t.TempDir()
+		cl.options = &Options{ReplayDir: dir}
+		done := make(chan struct{})
+		go func() { cl.listenCPU(bot.Difficulty{Name: "clumsy", Mistakes: 1}); close(done) }()
+		wait(t, done)
+		if cl.state.get() != lobby || render.lobbyCount != 1 {
+			t.Errorf("wanted to be back in the lobby after the cpu topped out")
+		}
+		if files, _ := os.ReadDir(dir); len(files) != 1 {
+			t.Errorf("wanted the replay of the game won saved, got %d files", len(files))
+		}
+	})
+}
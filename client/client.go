@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"tetris/bot"
+	"tetris/message"
 	"tetris/pb"
 	"tetris/tetris"
+	"time"
 
 	"github.com/eiannone/keyboard"
 	"google.golang.org/grpc"
@@ -23,8 +28,21 @@ const (
 	lobby clientState = iota
 	waiting
 	playing
+	playingOnline
+	playingServer
+	paused
+	replaying
+	watchingBot
+	choosingCPU
+	playingCPU
+	choosingOnline
+	typingRoom
+	spectating
+	watchingGame
 
 	serverPort = ":9000"
+	// maxRoomCode is the length of the longest room code that can be typed.
+	maxRoomCode = 12
 )
 
 type state struct {
@@ -44,18 +62,59 @@ func (s *state) set(c clientState) {
 	s.current = c
 }
 
+// swap sets the state to "to" if it's "from", and returns whether it did.
+func (s *state) swap(from, to clientState) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != from {
+		return false
+	}
+	s.current = to
+	return true
+}
+
 type tetrisGame interface {
-	Start()
+	Start(...tetris.Option)
 	GetUpdate() <-chan *tetris.Tetris
 	Action(tetris.Action)
 	Stop()
-	RemoteLines(i int32)
+	Pause()
+	Resume()
+	RemoteGarbage(i int32)
+	Replay() *tetris.Replay
+	Subscribe(tetris.EventHandler)
 }
 
 type renderer interface {
 	singlePlayer(*tetris.Tetris)
 	multiPlayer(*mpData)
 	lobby(msgSetter)
+	status(msgSetter)
+	flash(msgSetter, time.Duration)
+	linesCleared()
+}
+
+// events queues the events of the local game. the game waits for every
+// update to be received before going on, so the events popped as soon as
+// it's received are the ones that led to it, which are handled after
+// rendering it, see handleEvents.
+type events struct {
+	queue []tetris.Event
+	mu    sync.Mutex
+}
+
+func (e *events) push(ev tetris.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.queue = append(e.queue, ev)
+}
+
+func (e *events) pop() []tetris.Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	q := e.queue
+	e.queue = nil
+	return q
 }
 
 type Client struct {
@@ -65,12 +124,50 @@ type Client struct {
 	logger  *slog.Logger
 	kbCh    <-chan keyboard.KeyEvent
 	state   *state
+
+	// level and goal are the start level and leveling goal of single
+	// player games, which can be changed in the lobby.
+	level int
+	goal  tetris.Goal
+
+	// replayer plays back a replay instead of a game being played.
+	replayer *replayer
+	// cpu is the game of the bot in games against it.
+	cpu tetrisGame
+	// server is the game of the player in online matches the server runs.
+	server tetrisGame
+	// room is the room code being typed in the lobby.
+	room string
+	// target is how the garbage picks an opponent in online matches of
+	// more than two players.
+	target atomic.Int32
+	// events are the events of the local game not handled yet.
+	events events
 }
 
 type Options struct {
-	NoGhost bool
-	Address string
-	Name    string
+	NoGhost    bool
+	Address    string
+	Name       string
+	Seed       int64
+	Randomizer tetris.RandomizerKind
+	Previews   int
+	Level      int
+	Goal       tetris.Goal
+	LevelCap   int
+	// ReplayDir is where the replays of finished games are saved, none
+	// are saved if it's empty.
+	ReplayDir string
+	// Replay is played back instead of starting in the lobby.
+	Replay *tetris.Replay
+	// Room is the code of the room online games are played in, instead
+	// of playing against anyone.
+	Room string
+	// Players is the number of players of the online matches created.
+	Players int
+	// Spectate is the id of the online match watched instead of starting
+	// in the lobby.
+	Spectate int32
 }
 
 func New(l *slog.Logger, o *Options) (*Client, error) {
@@ -78,19 +175,44 @@ func New(l *slog.Logger, o *Options) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open keyboard: %w", err)
 	}
-	return &Client{
-		tetris:  tetris.NewGame(),
+	c := &Client{
+		tetris: tetris.NewGame(
+			tetris.WithSeed(o.Seed),
+			tetris.WithRandomizer(o.Randomizer),
+			tetris.WithPreviews(o.Previews),
+			tetris.WithLevelCap(o.LevelCap),
+		),
 		render:  newRender(l, o.NoGhost, o.Name),
 		options: o,
 		logger:  l,
 		kbCh:    kb,
 		state:   &state{current: lobby},
-	}, nil
+		level:   min(max(o.Level, 1), tetris.MaxStartLevel),
+		goal:    o.Goal,
+		cpu: tetris.NewGame(
+			tetris.WithRandomizer(o.Randomizer),
+			tetris.WithPreviews(o.Previews),
+		),
+	}
+	if o.Replay != nil {
+		c.replayer, c.tetris = newReplayer(o.Replay)
+	}
+	c.tetris.Subscribe(c.events.push)
+	return c, nil
 }
 
 func (c *Client) Start() {
 	c.render.singlePlayer(nil)
-	c.render.lobby(defaultLobby())
+	switch {
+	case c.replayer != nil:
+		c.state.set(replaying)
+		go c.listenReplay()
+	case c.options != nil && c.options.Spectate != 0:
+		c.state.set(watchingGame)
+		go c.listenSpectate(c.options.Spectate)
+	default:
+		c.render.lobby(defaultLobby())
+	}
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go c.listenKB(&wg)
@@ -118,18 +240,89 @@ func (c *Client) listenKB(wg *sync.WaitGroup) {
 		case lobby:
 			switch event.Rune {
 			case 'p':
-				go c.listenTetris()
+				go c.listenTetris(tetris.Marathon)
 				c.state.set(playing)
-			case 'o':
+			case 's':
+				go c.listenTetris(tetris.Sprint)
+				c.state.set(playing)
+			case 'u':
+				go c.listenTetris(tetris.Ultra)
+				c.state.set(playing)
+			case 'b':
 				ctx, cancel = context.WithCancel(context.Background())
 				defer cancel()
-				go c.listenOnlineTetris(ctx)
-				c.state.set(waiting)
+				go c.listenBot(ctx)
+				c.state.set(watchingBot)
+			case 'c':
+				c.state.set(choosingCPU)
+				c.render.lobby(cpuDifficulty())
+			case 'l':
+				c.level = c.level%tetris.MaxStartLevel + 1
+				c.render.lobby(settings(c.level, c.goal))
+			case 'g':
+				if c.goal == tetris.VariableGoal {
+					c.goal = tetris.FixedGoal
+				} else {
+					c.goal = tetris.VariableGoal
+				}
+				c.render.lobby(settings(c.level, c.goal))
+			case 'o':
+				if c.options != nil && c.options.Room != "" {
+					ctx, cancel = context.WithCancel(context.Background())
+					defer cancel()
+					go c.listenOnlineTetris(ctx, room{code: c.options.Room})
+					c.state.set(waiting)
+					continue
+				}
+				c.state.set(choosingOnline)
+				c.render.lobby(onlineMenu())
 			case 'q':
 				return
 			default:
 				continue
 			}
+		case choosingOnline:
+			var r room
+			switch event.Rune {
+			case 'm':
+			case 'n':
+				r.create = true
+			case 'j':
+				c.room = ""
+				c.state.set(typingRoom)
+				c.render.lobby(roomPrompt(c.room))
+				continue
+			case 'b':
+				c.state.set(lobby)
+				c.render.lobby(defaultLobby())
+				continue
+			default:
+				continue
+			}
+			ctx, cancel = context.WithCancel(context.Background())
+			defer cancel()
+			go c.listenOnlineTetris(ctx, r)
+			c.state.set(waiting)
+		case typingRoom:
+			switch {
+			case event.Key == keyboard.KeyEnter && c.room != "":
+				ctx, cancel = context.WithCancel(context.Background())
+				defer cancel()
+				go c.listenOnlineTetris(ctx, room{code: c.room})
+				c.state.set(waiting)
+				continue
+			case event.Key == keyboard.KeyEsc:
+				c.state.set(lobby)
+				c.render.lobby(defaultLobby())
+				continue
+			case (event.Key == keyboard.KeyBackspace || event.Key == keyboard.KeyBackspace2) && c.room != "":
+				c.room = c.room[:len(c.room)-1]
+			case isRoomRune(event.Rune) && len(c.room) < maxRoomCode:
+				c.room += strings.ToUpper(string(event.Rune))
+			default:
+				continue
+			}
+			c.render.lobby(roomPrompt(c.room))
 		case waiting:
 			switch event.Rune {
 			case 'c':
@@ -138,9 +331,57 @@ func (c *Client) listenKB(wg *sync.WaitGroup) {
 			default:
 				continue
 			}
-		case playing:
+		case choosingCPU:
+			d, ok := map[rune]bot.Difficulty{'e': bot.Easy, 'm': bot.Medium, 'h': bot.Hard}[event.Rune]
+			switch {
+			case ok:
+				go c.listenCPU(d)
+				c.state.set(playingCPU)
+			case event.Rune == 'b':
+				c.state.set(lobby)
+				c.render.lobby(defaultLobby())
+			}
+		case watchingBot:
+			if event.Rune == 'q' || event.Key == keyboard.KeyEsc {
+				cancel()
+			}
+		case spectating:
+			if event.Rune == 'q' || event.Key == keyboard.KeyEsc {
+				cancel()
+				c.render.lobby(defaultLobby())
+			}
+		case watchingGame:
+			if event.Rune == 'q' {
+				return
+			}
+		case replaying:
+			if event.Rune == 'q' {
+				return
+			}
+			// keys are dropped while the replay is busy playing an event.
+			select {
+			case c.replayer.ctlCh <- event.Rune:
+			default:
+			}
+		case paused:
+			switch {
+			case event.Rune == 'p' || event.Key == keyboard.KeyEsc:
+				c.tetris.Resume()
+				c.state.set(playing)
+			default:
+				continue
+			}
+		case playing, playingOnline, playingServer, playingCPU:
 			var a tetris.Action
 			switch {
+			case event.Rune == 'p' || event.Key == keyboard.KeyEsc:
+				// there's no pausing a game against someone else. a game
+				// that ends as it's paused has already left for the lobby.
+				if c.state.get() == playing {
+					c.tetris.Pause()
+					c.state.swap(playing, paused)
+				}
+				continue
 			case event.Key == keyboard.KeyArrowDown || event.Rune == 's':
 				a = tetris.MoveDown
 			case event.Key == keyboard.KeyArrowLeft || event.Rune == 'a':
@@ -153,25 +394,80 @@ func (c *Client) listenKB(wg *sync.WaitGroup) {
 				a = tetris.RotateLeft
 			case event.Key == keyboard.KeySpace:
 				a = tetris.DropDown
+			case event.Rune == 'c':
+				a = tetris.Hold
+			case event.Rune == 't':
+				// the target only matters in online matches of more than two players.
+				c.target.Store((c.target.Load() + 1) % int32(len(targetNames))) // nolint:gosec
+				continue
+			default:
+				continue
+			}
+			if c.state.get() == playingServer {
+				c.server.Action(a)
+				continue
 			}
 			c.tetris.Action(a)
 		}
 	}
 }
 
-func (c *Client) listenTetris() {
-	go c.tetris.Start()
+func (c *Client) handleEvents(evs []tetris.Event) {
+	// handleEvents() handles the events of the local game that led to
+	// the update just rendered. the end of the game is left to the
+	// update, which is its last.
+	for _, e := range evs {
+		switch e := e.(type) {
+		case *tetris.LinesCleared:
+			c.render.linesCleared()
+		case tetris.LevelUp:
+			c.render.flash(levelUp(e.Level), levelUpTime)
+		}
+	}
+}
+
+func (c *Client) listenTetris(m tetris.Mode) {
+	c.events.pop()
+	go c.tetris.Start(tetris.WithMode(m), tetris.WithStartLevel(c.level), tetris.WithGoal(c.goal))
 	for u := range c.tetris.GetUpdate() {
+		evs := c.events.pop()
 		c.render.singlePlayer(u)
+		if u.Paused {
+			c.render.lobby(pausedGame())
+		}
+		c.handleEvents(evs)
 		if u.GameOver {
+			c.tetris.Stop()
+			c.saveReplay()
 			c.state.set(lobby)
-			c.render.lobby(gameOver())
+			switch {
+			case u.Won && u.Mode == tetris.Sprint:
+				c.render.lobby(sprintFinished(u.Time))
+			case u.Mode == tetris.Ultra:
+				c.render.lobby(ultraFinished(u.Score, u.Won))
+			case u.Won:
+				c.render.lobby(marathonFinished(u.Score))
+			default:
+				c.render.lobby(gameOver())
+			}
 			return
 		}
 	}
 }
 
-func (c *Client) listenOnlineTetris(ctx context.Context) {
+// room is the room an online game is played in.
+type room struct {
+	// code is the room to join, the game is against anyone without one.
+	code string
+	// create asks the server for a new room.
+	create bool
+}
+
+func isRoomRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func (c *Client) listenOnlineTetris(ctx context.Context, r room) {
 	defer func() {
 		c.state.set(lobby)
 		c.tetris.Stop()
@@ -196,10 +492,18 @@ func (c *Client) listenOnlineTetris(ctx context.Context) {
 		return
 	}
 	defer stream.CloseSend() //nolint: errcheck
-	c.render.lobby(waitingOpponent())
+	if r.code != "" {
+		c.render.lobby(waitingRoom(strings.ToUpper(r.code)))
+	} else {
+		c.render.lobby(waitingOpponent())
+	}
 
-	// Set receiver channel
+	// Set receiver channel. The states of the game the server runs in
+	// authoritative matches go to sg instead.
 	rcvCh := make(chan *pb.GameMessage)
+	sg := newServerGame(&c.target)
+	sg.Subscribe(c.events.push)
+	defer sg.Stop()
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		defer func() {
@@ -219,87 +523,174 @@ func (c *Client) listenOnlineTetris(ctx context.Context) {
 				} else if ok && st.Code() == codes.DeadlineExceeded {
 					c.logger.Debug("stream.Recv() closed with DeadlineExceeded", slog.String("msg", st.Message()))
 					c.render.lobby(waitingOpponentError())
+				} else if ok && st.Code() == codes.NotFound {
+					c.logger.Debug("stream.Recv() closed with NotFound", slog.String("msg", st.Message()))
+					c.render.lobby(roomNotFound(strings.ToUpper(r.code)))
 				} else {
 					c.logger.Error("stream.Recv() unable to receive message", slog.String("error", err.Error()))
 					c.render.lobby(errorMessage())
 				}
 				return
 			}
+			if rcv.HasState() {
+				sg.update(rcv)
+				continue
+			}
 			rcvCh <- rcv
 		}
 	}()
 
 	// Send initial message, wait for game to start.
-	if err := stream.Send(pb.GameMessage_builder{Name: proto.String(c.options.Name)}.Build()); err != nil {
+	if err := stream.Send(pb.GameMessage_builder{
+		Name:    proto.String(c.options.Name),
+		Room:    proto.String(r.code),
+		NewRoom: proto.Bool(r.create),
+		Players: proto.Int32(int32(c.options.Players)), // nolint:gosec
+	}.Build()); err != nil {
 		c.logger.Error("unable to send initial message", slog.String("error", err.Error()))
 		return
 	}
 	c.render.multiPlayer(&mpData{remote: &pb.GameMessage{}})
+	var b *battle
+	var authoritative bool
 start:
 	for {
 		select {
 		case rcv := <-rcvCh:
 			if rcv.GetIsStarted() {
+				b = newBattle(int(rcv.GetPlayers()))
+				authoritative = rcv.GetAuthoritative()
 				break start
 			}
+			if code := rcv.GetRoom(); code != "" {
+				// the room created for the game, to share with the opponent.
+				c.render.lobby(waitingRoom(code))
+			}
 		case <-ctx.Done():
 			c.logger.Debug("start for loop ctx.Done() was closed")
 			return
 		}
 	}
 
-	// start game
-	c.state.set(playing)
-	go c.tetris.Start()
+	// start game. in authoritative matches the server runs the game of
+	// the player, which only sends its actions.
+	c.target.Store(int32(pb.Target_TARGET_RANDOM))
+	c.events.pop()
+	game := tetrisGame(c.tetris)
+	if authoritative {
+		game, c.server = sg, sg
+		c.state.set(playingServer)
+	} else {
+		c.state.set(playingOnline)
+		go c.tetris.Start(tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(1), tetris.WithGoal(tetris.FixedGoal), tetris.WithLevelCap(0))
+	}
 
+	// updates stops once the player is knocked out of a match that goes
+	// on, which they keep watching. a match won leaves the local game
+	// running, which is stopped for its replay to be saved.
+	updates := game.GetUpdate()
+	stopLocal := func() {
+		if !authoritative {
+			c.tetris.Stop()
+			c.saveReplay()
+		}
+	}
 	for {
 		select {
-		case lu, ok := <-c.tetris.GetUpdate():
+		case lu, ok := <-updates:
 			if !ok {
 				c.logger.Error("listenOnline tetris update channel closed unexpectedly")
 				return
 			}
-			c.render.multiPlayer(&mpData{local: lu})
-			if err := stream.Send(pb.GameMessage_builder{
-				Name:       proto.String(c.options.Name),
-				IsGameOver: proto.Bool(lu.GameOver),
-				IsStarted:  proto.Bool(true),
-				LinesClear: proto.Int32(int32(lu.LinesClear)), // nolint:gosec
-				Stack:      stack2Proto(lu),
-			}.Build()); err != nil {
-				if err == io.EOF {
-					c.logger.Debug("send() opponent closed the game with EOF", slog.String("debug", err.Error()))
-					return
-				}
-				st, ok := status.FromError(err)
-				if ok && st.Code() == codes.Canceled {
-					c.logger.Debug("send() opponent closed the game with Cancel", slog.String("debug", err.Error()))
+			evs := c.events.pop()
+			c.renderOnline(b, lu, nil)
+			if !authoritative {
+				gm := message.FromGame(c.options.Name, lu)
+				gm.SetTarget(pb.Target(c.target.Load()))
+				if !c.send(stream, gm) {
 					return
 				}
-				c.logger.Error("send() unable to send message", slog.String("error", err.Error()))
-				return
 			}
+			c.handleEvents(evs)
 			if lu.GameOver {
-				c.logger.Debug("listenOnline closed through local.GameOver")
-				c.render.lobby(gameOver())
-				return
+				stopLocal()
+				if b.playing() < 2 {
+					c.logger.Debug("listenOnline closed through local.GameOver")
+					c.render.lobby(gameOver())
+					return
+				}
+				c.logger.Debug("listenOnline knocked out through local.GameOver")
+				c.state.set(spectating)
+				c.render.lobby(knockedOut(b.playing() + 1))
+				updates = nil
 			}
 		case ru, ok := <-rcvCh:
 			if !ok {
 				c.logger.Error("listenOnline remote update channel closed unexpectedly")
 				return
 			}
-			c.tetris.RemoteLines(ru.GetLinesClear())
-			c.render.multiPlayer(&mpData{remote: ru})
-			if ru.GetIsGameOver() {
+			b.update(ru)
+			game.RemoteGarbage(ru.GetGarbageReceived())
+			c.renderOnline(b, nil, ru)
+			switch {
+			case updates == nil && b.playing() < 2:
+				c.logger.Debug("listenOnline closed through the match being over")
+				c.render.lobby(matchOver(b.winner()))
+				return
+			case updates == nil || b.playing() > 0:
+				// the match goes on.
+			case b.players == 2 && ru.GetLeft():
+				c.logger.Debug("listenOnline closed through remote.GetLeft()")
+				stopLocal()
+				c.render.lobby(opponentLeft())
+				return
+			default:
 				c.logger.Debug("listenOnline closed through remote.GetIsGameOver()")
+				stopLocal()
 				c.render.lobby(youWon())
 				return
 			}
+		case gm := <-sg.actions:
+			if !c.send(stream, gm) {
+				return
+			}
 		case <-ctx.Done():
 			c.logger.Debug("listenOnline ctx.Done() was closed")
-			c.render.lobby(opponentLeft())
+			if updates != nil {
+				c.render.lobby(opponentLeft())
+			}
 			return
 		}
 	}
 }
+
+func (c *Client) send(stream grpc.BidiStreamingClient[pb.GameMessage, pb.GameMessage], gm *pb.GameMessage) bool {
+	// send() sends a message of the player to the server, and returns
+	// false if the match is over for them.
+	err := stream.Send(gm)
+	if err == nil {
+		return true
+	}
+	if err == io.EOF {
+		c.logger.Debug("send() opponent closed the game with EOF", slog.String("debug", err.Error()))
+		return false
+	}
+	st, ok := status.FromError(err)
+	if ok && st.Code() == codes.Canceled {
+		c.logger.Debug("send() opponent closed the game with Cancel", slog.String("debug", err.Error()))
+		return false
+	}
+	c.logger.Error("send() unable to send message", slog.String("error", err.Error()))
+	return false
+}
+
+func (c *Client) renderOnline(b *battle, lu *tetris.Tetris, ru *pb.GameMessage) {
+	// renderOnline() renders the opponent next to the local game in
+	// matches of two players, and mini boards of all the opponents in
+	// bigger ones.
+	if b.players == 2 {
+		c.render.multiPlayer(&mpData{local: lu, remote: ru})
+		return
+	}
+	c.render.multiPlayer(&mpData{local: lu, opponents: b.list(), target: pb.Target(c.target.Load())})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"tetris/tetris"
@@ -15,26 +16,54 @@ import (
 type mockTetris struct {
 	updateCh chan *tetris.Tetris
 	start    bool
+	opts     []tetris.Option
 	stop     bool
+	paused   bool
 	action   tetris.Action
+	handler  tetris.EventHandler
 }
 
 func (m *mockTetris) Stop()                            { m.stop = true }
 func (m *mockTetris) GetUpdate() <-chan *tetris.Tetris { return m.updateCh }
-func (m *mockTetris) Start()                           { m.start = true; m.updateCh <- &tetris.Tetris{} }
-func (m *mockTetris) Action(a tetris.Action)           { m.action = a; m.updateCh <- &tetris.Tetris{} }
-func (m *mockTetris) RemoteLines(int32)                {}
-func (m *mockTetris) sendGameOver()                    { m.updateCh <- &tetris.Tetris{GameOver: true} }
+func (m *mockTetris) Start(o ...tetris.Option) {
+	m.start, m.opts = true, o
+	m.updateCh <- &tetris.Tetris{}
+}
+func (m *mockTetris) Action(a tetris.Action)          { m.action = a; m.updateCh <- &tetris.Tetris{} }
+func (m *mockTetris) Pause()                          { m.paused = true; m.updateCh <- &tetris.Tetris{Paused: true} }
+func (m *mockTetris) Resume()                         { m.paused = false; m.updateCh <- &tetris.Tetris{} }
+func (m *mockTetris) Replay() *tetris.Replay          { return &tetris.Replay{Mode: tetris.Marathon} }
+func (m *mockTetris) RemoteGarbage(int32)             {}
+func (m *mockTetris) Subscribe(h tetris.EventHandler) { m.handler = h }
+func (m *mockTetris) sendGameOver() {
+	m.handler(tetris.GameOver{})
+	m.updateCh <- &tetris.Tetris{GameOver: true}
+}
+
+// overTetris is a mockTetris whose game ends as it's paused.
+type overTetris struct {
+	*mockTetris
+	state *state
+}
+
+func (o *overTetris) Pause() { o.state.set(lobby) }
 
 type mockRender struct {
 	lobbyCount        int
 	singlePlayerCount int
 	multiPlayerCount  int
+	statusCount       int
+	flashCount        int
+	linesClearedCount int
+	local             *tetris.Tetris
 }
 
-func (m *mockRender) multiPlayer(*mpData)         { m.multiPlayerCount++ }
-func (m *mockRender) lobby(msgSetter)             { m.lobbyCount++ }
-func (m *mockRender) singlePlayer(*tetris.Tetris) { m.singlePlayerCount++ }
+func (m *mockRender) multiPlayer(*mpData)            { m.multiPlayerCount++ }
+func (m *mockRender) lobby(msgSetter)                { m.lobbyCount++ }
+func (m *mockRender) status(msgSetter)               { m.statusCount++ }
+func (m *mockRender) singlePlayer(t *tetris.Tetris)  { m.singlePlayerCount++; m.local = t }
+func (m *mockRender) flash(msgSetter, time.Duration) { m.flashCount++ }
+func (m *mockRender) linesCleared()                  { m.linesClearedCount++ }
 
 func TestClient(t *testing.T) {
 	render := &mockRender{}
@@ -46,7 +75,10 @@ func TestClient(t *testing.T) {
 		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		kbCh:   kCh,
 		state:  &state{current: lobby},
+		level:  1,
+		goal:   tetris.FixedGoal,
 	}
+	tts.Subscribe(cl.events.push)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -82,6 +114,7 @@ func TestClient(t *testing.T) {
 		{key: keyboard.KeyEvent{Key: keyboard.KeyArrowUp}, action: tetris.RotateRight},
 		{key: keyboard.KeyEvent{Rune: 'q'}, action: tetris.RotateLeft},
 		{key: keyboard.KeyEvent{Key: keyboard.KeySpace}, action: tetris.DropDown},
+		{key: keyboard.KeyEvent{Rune: 'c'}, action: tetris.Hold},
 	}
 	for _, a := range actions {
 		wantLocalCount++
@@ -97,20 +130,97 @@ func TestClient(t *testing.T) {
 		})
 	}
 
+	// 'p' and esc should pause and resume the game, rendering the pause in the lobby box.
+	wantLobbyCount := 1
+	for _, key := range []keyboard.KeyEvent{{Rune: 'p'}, {Key: keyboard.KeyEsc}} {
+		wantLocalCount++
+		wantLobbyCount++
+		kCh <- key
+		time.Sleep(10 * time.Millisecond)
+		if !tts.paused || cl.state.get() != paused {
+			t.Errorf("wanted game to be paused after %v", key)
+		}
+		if render.lobbyCount != wantLobbyCount {
+			t.Errorf("wanted render.lobby() to be called %d times, got %d", wantLobbyCount, render.lobbyCount)
+		}
+
+		// actions are not sent while paused.
+		kCh <- keyboard.KeyEvent{Rune: 'a'}
+		wantLocalCount++
+		kCh <- key
+		time.Sleep(10 * time.Millisecond)
+		if tts.paused || cl.state.get() != playing {
+			t.Errorf("wanted game to be resumed after %v", key)
+		}
+		if render.singlePlayerCount != wantLocalCount {
+			t.Errorf("wanted render.local() to be %d times, got %d", wantLocalCount, render.singlePlayerCount)
+		}
+	}
+
 	// tetris.GameOver should render.local(), render.lobby() and set lobby to true.
 	wantLocalCount++
+	wantLobbyCount++
 	tts.sendGameOver()
 	time.Sleep(10 * time.Millisecond)
 	if render.singlePlayerCount != wantLocalCount {
 		t.Errorf("wanted render.local() to be %d times, got %d", wantLocalCount, render.singlePlayerCount)
 	}
-	if render.lobbyCount != 2 {
-		t.Errorf("wanted render.lobby() to be called 2 times, got %d", render.lobbyCount)
+	if render.lobbyCount != wantLobbyCount {
+		t.Errorf("wanted render.lobby() to be called %d times, got %d", wantLobbyCount, render.lobbyCount)
 	}
 	if cl.state.get() != lobby {
 		t.Errorf("wanted lobby to be true")
 	}
 
+	// 'l' and 'g' should change the start level and goal in the lobby.
+	kCh <- keyboard.KeyEvent{Rune: 'l'}
+	kCh <- keyboard.KeyEvent{Rune: 'g'}
+	time.Sleep(10 * time.Millisecond)
+	wantLobbyCount += 2
+	if cl.level != 2 || cl.goal != tetris.VariableGoal {
+		t.Errorf("wanted level 2 with variable goal, got level %d with %q goal", cl.level, cl.goal)
+	}
+	if render.lobbyCount != wantLobbyCount {
+		t.Errorf("wanted render.lobby() to be called %d times, got %d", wantLobbyCount, render.lobbyCount)
+	}
+
+	// 's' and 'u' should start a sprint and an ultra, which also end in the lobby.
+	for _, r := range []rune{'s', 'u'} {
+		tts.start = false
+		wantLocalCount++
+		kCh <- keyboard.KeyEvent{Rune: r}
+		time.Sleep(10 * time.Millisecond)
+		if !tts.start || len(tts.opts) != 3 {
+			t.Errorf("wanted tetris.Start() to be called with the mode, level and goal options, got %t with %d options", tts.start, len(tts.opts))
+		}
+		if cl.state.get() != playing {
+			t.Errorf("wanted client state to be 'playing' after '%c' key press", r)
+		}
+		wantLocalCount++
+		tts.sendGameOver()
+		time.Sleep(10 * time.Millisecond)
+		if render.singlePlayerCount != wantLocalCount {
+			t.Errorf("wanted render.local() to be %d times, got %d", wantLocalCount, render.singlePlayerCount)
+		}
+		if cl.state.get() != lobby {
+			t.Errorf("wanted lobby to be true")
+		}
+	}
+
+	// 'o' and 'j' should prompt for a room code, typed in uppercase, and esc goes back to the lobby.
+	for _, key := range []keyboard.KeyEvent{{Rune: 'o'}, {Rune: 'j'}, {Rune: 'k'}, {Rune: '7'}, {Rune: '-'}, {Key: keyboard.KeyBackspace2}, {Rune: 'x'}} {
+		kCh <- key
+	}
+	time.Sleep(10 * time.Millisecond)
+	if cl.state.get() != typingRoom || cl.room != "KX" {
+		t.Errorf("wanted to be typing room code %q, got %q", "KX", cl.room)
+	}
+	kCh <- keyboard.KeyEvent{Key: keyboard.KeyEsc}
+	time.Sleep(10 * time.Millisecond)
+	if cl.state.get() != lobby {
+		t.Errorf("wanted to be back in the lobby after esc")
+	}
+
 	// 'q' should quit the game back in the lobby"
 	kCh <- keyboard.KeyEvent{Rune: 'q'}
 	wgDone := make(chan struct{})
@@ -121,3 +231,104 @@ func TestClient(t *testing.T) {
 	case <-wgDone:
 	}
 }
+
+func TestPauseGameOver(t *testing.T) {
+	kCh := make(chan keyboard.KeyEvent)
+	cl := &Client{
+		render: &mockRender{},
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		kbCh:   kCh,
+		state:  &state{current: playing},
+	}
+	cl.tetris = &overTetris{mockTetris: &mockTetris{}, state: cl.state}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go cl.listenKB(&wg)
+	kCh <- keyboard.KeyEvent{Rune: 'p'}
+	kCh <- keyboard.KeyEvent{Key: keyboard.KeyCtrlC}
+	wg.Wait()
+	if cl.state.get() != lobby {
+		t.Errorf("wanted the game that ended as it was paused to stay in the lobby, got %v", cl.state.get())
+	}
+}
+
+func TestSaveReplay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "replays")
+	cl := &Client{
+		tetris:  &mockTetris{},
+		options: &Options{ReplayDir: dir},
+		logger:  slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	// replays saved in the same second don't overwrite each other.
+	cl.saveReplay()
+	cl.saveReplay()
+	files, err := filepath.Glob(filepath.Join(dir, "*-marathon.json"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("wanted 2 replay files, got %v with error %v", files, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint: errcheck
+	if r, err := tetris.ReadReplay(f); err != nil || r.Mode != tetris.Marathon {
+		t.Errorf("wanted a marathon replay, got %+v with error %v", r, err)
+	}
+}
+
+func TestListenTetrisGameOver(t *testing.T) {
+	render := &mockRender{}
+	tts := &mockTetris{updateCh: make(chan *tetris.Tetris)}
+	cl := &Client{
+		tetris: tts,
+		render: render,
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		state:  &state{current: playing},
+	}
+	tts.Subscribe(cl.events.push)
+	done := make(chan struct{})
+	go func() { cl.listenTetris(tetris.Marathon); close(done) }()
+	send := func(u *tetris.Tetris) {
+		t.Helper()
+		select {
+		case tts.updateCh <- u:
+		case <-done:
+			t.Fatal("wanted the game to go on until its last update")
+		}
+	}
+
+	// the game ends while the update before its last is rendered.
+	send(&tetris.Tetris{})
+	cl.events.push(tetris.GameOver{Score: 100})
+	send(&tetris.Tetris{})
+	send(&tetris.Tetris{GameOver: true, Score: 100})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the game to end")
+	}
+	if !tts.stop || cl.state.get() != lobby || render.lobbyCount != 1 {
+		t.Errorf("wanted the game stopped and the client in the lobby, got stopped %t and state %v", tts.stop, cl.state.get())
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	render := &mockRender{}
+	cl := &Client{render: render}
+	for _, e := range []tetris.Event{
+		tetris.PieceLocked{Shape: tetris.I},
+		&tetris.LinesCleared{Rows: []int{0}},
+		tetris.LevelUp{Level: 2},
+		tetris.GameOver{Won: true, Score: 100},
+	} {
+		cl.events.push(e)
+	}
+	cl.handleEvents(cl.events.pop())
+	if render.linesClearedCount != 1 || render.flashCount != 1 {
+		t.Errorf("wanted the line clear animation and the level up, got %d and %d", render.linesClearedCount, render.flashCount)
+	}
+	if evs := cl.events.pop(); len(evs) != 0 {
+		t.Errorf("wanted the events to be handled once, got %v", evs)
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"tetris/pb"
 	"tetris/tetris"
 	"text/template"
+	"time"
 )
 
 type msgSetter func(io.Writer)
@@ -23,8 +26,30 @@ const (
 	Green   = "32"
 	Red     = "31"
 	Magenta = "35"
+	Gray    = "90"
 
 	resetPos = "\033[H" // Reset cursor position to 0,0
+
+	// the line clear animation blinks the cleared lines during the line
+	// clear delay of the game.
+	lineClearFrames    = 8
+	lineClearFrameTime = 40 * time.Millisecond
+	// levelUpTime is how long a level up is shown below the game.
+	levelUpTime = 2 * time.Second
+
+	maxPreviews = 6
+
+	// miniBoards is how many mini boards of opponents fit in a row, each
+	// one is half the height of a stack.
+	miniBoards     = 4
+	miniBoardWidth = 10
+	miniBoardLines = 10
+	// miniBoardsColumn is where the mini boards start, after the stack
+	// and the info column.
+	miniBoardsColumn = 59
+
+	// menu is the list of options of the lobby box.
+	menu = "\033[13;9H|  (p)lay (s)print (u)ltra (b)ot demo  |\033[14;9H| (l)evel (g)oal (o)nline (c)pu (q)uit |"
 )
 
 var (
@@ -32,6 +57,8 @@ var (
 	layoutSP string
 	//go:embed "layout_mp.tmpl"
 	layoutMP string
+	//go:embed "layout_br.tmpl"
+	layoutBR string
 )
 
 var colorMap = map[tetris.Shape]string{
@@ -42,6 +69,8 @@ var colorMap = map[tetris.Shape]string{
 	tetris.S: Green,
 	tetris.Z: Red,
 	tetris.T: Magenta,
+
+	tetris.Garbage: Gray,
 }
 
 type templateData struct {
@@ -49,6 +78,13 @@ type templateData struct {
 	Remote  *pb.GameMessage
 	Name    string
 	NoGhost bool
+	// Hidden are the rows of the local stack hidden by the line clear animation.
+	Hidden []int
+	// Opponents are the opponents of matches of more than two players,
+	// rendered as mini boards, and Target is how the local garbage picks
+	// one of them.
+	Opponents []*pb.GameMessage
+	Target    pb.Target
 }
 
 type render struct {
@@ -56,6 +92,16 @@ type render struct {
 	logger   *slog.Logger
 	template *template.Template
 	*templateData
+	// mu serializes the frames of the line clear animation with the
+	// rendering of updates.
+	mu sync.Mutex
+	// layout is the last layout rendered.
+	layout string
+	// statusMsg is the line below the game, which flashes replace for a
+	// while. flashes counts the changes of the line, so a flash only
+	// brings back the status it replaced.
+	statusMsg msgSetter
+	flashes   int
 }
 
 func newRender(l *slog.Logger, ng bool, name string) *render {
@@ -71,36 +117,132 @@ func newRender(l *slog.Logger, ng bool, name string) *render {
 }
 
 func (r *render) lobby(msg msgSetter) {
-	fmt.Fprint(r.writer, "\033[10;9H+--------------------------------------+\033[11;9H|                                      |\033[12;9H|                                      |\033[13;9H|                                      |\033[14;9H+--------------------------------------+")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprint(r.writer, "\033[10;9H+--------------------------------------+\033[11;9H|                                      |\033[12;9H|                                      |\033[13;9H|                                      |\033[14;9H|                                      |\033[15;9H+--------------------------------------+")
 	msg(r.writer)
 }
 
+func (r *render) status(msg msgSetter) {
+	// status() writes a line below the game.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusMsg = msg
+	r.flashes++
+	r.writeStatus(msg)
+}
+
+func (r *render) flash(msg msgSetter, d time.Duration) {
+	// flash() writes a line below the game for d, after which the status
+	// it replaced is back.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flashes++
+	n := r.flashes
+	r.writeStatus(msg)
+	time.AfterFunc(d, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.flashes == n {
+			r.writeStatus(r.statusMsg)
+		}
+	})
+}
+
+func (r *render) writeStatus(msg msgSetter) {
+	fmt.Fprint(r.writer, "\033[23;1H\033[2K")
+	if msg != nil {
+		msg(r.writer)
+	}
+}
+
 func (r *render) singlePlayer(t *tetris.Tetris) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.Remote != nil {
 		// ensures no remote data is in templateData from previous games
 		r.Remote = nil
 	}
 	r.Local = t
-	if err := r.template.ExecuteTemplate(r.writer, "layoutSP", r.templateData); err != nil {
-		r.logger.Error("unable to execute template", slog.String("error", err.Error()))
-	}
+	r.execute("layoutSP")
 }
 
 type mpData struct {
 	remote *pb.GameMessage
 	local  *tetris.Tetris
+	// opponents renders a match of more than two players instead of the
+	// remote game.
+	opponents []*pb.GameMessage
+	target    pb.Target
+	// name replaces the name of the local game, which spectators render
+	// from one of the players.
+	name string
 }
 
 func (r *render) multiPlayer(mpd *mpData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	layout := "layoutMP"
 	if mpd != nil {
 		if mpd.remote != nil {
 			r.Remote = mpd.remote
 		}
+		if mpd.opponents != nil {
+			layout = "layoutBR"
+			r.Opponents, r.Target = mpd.opponents, mpd.target
+		}
+		if mpd.name != "" {
+			r.Name = mpd.name
+		}
 		if mpd.local != nil {
 			r.Local = mpd.local
 		}
 	}
-	if err := r.template.ExecuteTemplate(r.writer, "layoutMP", r.templateData); err != nil {
+	r.execute(layout)
+}
+
+func (r *render) linesCleared() {
+	// linesCleared() starts the line clear animation of the local game,
+	// in the layout it was last rendered in.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	go r.animate(r.layout)
+}
+
+func (r *render) animate(layout string) {
+	// animate() blinks the cleared lines of the local game until the
+	// animation is over or the game removes them.
+	defer func() {
+		r.mu.Lock()
+		r.Hidden = nil
+		r.mu.Unlock()
+	}()
+	for i := range lineClearFrames {
+		r.mu.Lock()
+		if r.Local == nil || r.Local.LinesCleared == nil {
+			r.mu.Unlock()
+			return
+		}
+		r.Hidden = nil
+		if i%2 == 0 {
+			r.Hidden = r.Local.LinesCleared.Rows
+		}
+		r.execute(layout)
+		r.mu.Unlock()
+		time.Sleep(lineClearFrameTime)
+	}
+}
+
+func (r *render) execute(layout string) {
+	if r.layout == "layoutBR" && layout != r.layout {
+		// the mini boards go past the side of the other layouts, which
+		// don't overwrite them.
+		for l := 1; l <= len(opponents(nil)); l++ {
+			fmt.Fprintf(r.writer, "\033[%d;%dH\033[K", l, miniBoardsColumn)
+		}
+	}
+	r.layout = layout
+	if err := r.template.ExecuteTemplate(r.writer, layout, r.templateData); err != nil {
 		r.logger.Error("unable to execute template", slog.String("error", err.Error()))
 	}
 }
@@ -109,9 +251,19 @@ func loadTemplate() *template.Template {
 	funcMap := template.FuncMap{
 		"localStack":       localStack,
 		"remoteStack":      remoteStack,
-		"nextPiece":        nextPiece,
+		"nextQueue":        nextQueue,
+		"holdPiece":        holdPiece,
+		"lastClear":        lastClear,
+		"chain":            chain,
+		"timer":            timer,
 		"remoteName":       remoteName,
 		"remoteLinesClear": remoteLinesClear,
+		"localMeter":       localMeter,
+		"remoteMeter":      remoteMeter,
+		"remoteScore":      remoteScore,
+		"opponents":        opponents,
+		"battleStatus":     battleStatus,
+		"target":           target,
 	}
 
 	// we use the console raw so new lines don't automatically transform into carriage return
@@ -124,9 +276,14 @@ func loadTemplate() *template.Template {
 	layoutMP = strings.ReplaceAll(layoutMP, "\n", "\r\n")
 	layoutMP = strings.ReplaceAll(layoutMP, "Terminal Tetris", "\033[1mTerminal Tetris\033[0m")
 
+	layoutBR = resetPos + layoutBR
+	layoutBR = strings.ReplaceAll(layoutBR, "\n", "\r\n")
+	layoutBR = strings.ReplaceAll(layoutBR, "Terminal Tetris", "\033[1mTerminal Tetris\033[0m")
+
 	tmpl := template.New("").Funcs(funcMap)
 	tmpl = template.Must(tmpl.New("layoutSP").Parse(layoutSP))
 	tmpl = template.Must(tmpl.New("layoutMP").Parse(layoutMP))
+	tmpl = template.Must(tmpl.New("layoutBR").Parse(layoutBR))
 
 	return tmpl
 }
@@ -136,7 +293,7 @@ func localStack(t *templateData) [20][10]string {
 	for y := range 20 {
 		for x := range 10 {
 			out := "  "
-			if t != nil && t.Local != nil {
+			if t != nil && t.Local != nil && !t.Local.Paused && !slices.Contains(t.Hidden, y) {
 				v := t.Local.Stack[y][x]
 				c, ok := colorMap[v]
 				if ok {
@@ -151,7 +308,7 @@ func localStack(t *templateData) [20][10]string {
 	}
 
 	// renders the current tetromino if exist
-	if t != nil && t.Local != nil && t.Local.Tetromino != nil {
+	if t != nil && t.Local != nil && !t.Local.Paused && t.Local.Tetromino != nil {
 		for iy, y := range t.Local.Tetromino.Grid {
 			for ix, x := range y {
 				if x {
@@ -186,14 +343,92 @@ func remoteStack(t *templateData) [20][10]string {
 	return rendered
 }
 
-func nextPiece(t *templateData) []string {
+func nextQueue(t *templateData, firstRow int) [20]string {
+	// nextQueue renders the Next queue as a column along the rows of the stack.
+	// the label goes in the row above firstRow and every preview takes two
+	// rows plus one for spacing, up to maxPreviews.
+	rendered := [20]string{}
+	for i := range rendered {
+		rendered[i] = "        "
+	}
+	rendered[firstRow-1] = "Next:   "
+	var next []*tetris.Tetromino
+	if t != nil && t.Local != nil && !t.Local.Paused {
+		next = t.Local.Next
+	}
+	for i := range min(len(next), maxPreviews) {
+		row := firstRow + i*3
+		if row+1 >= len(rendered) {
+			break
+		}
+		p := pieceRows(next[i])
+		rendered[row], rendered[row+1] = p[0], p[1]
+	}
+	return rendered
+}
+
+func holdPiece(t *templateData) []string {
+	if t == nil || t.Local == nil || t.Local.Paused {
+		return pieceRows(nil)
+	}
+	return pieceRows(t.Local.HoldTetromino)
+}
+
+func lastClear(t *templateData) string {
+	if t == nil || t.Local == nil {
+		return ""
+	}
+	return t.Local.LastClear.String()
+}
+
+func chain(t *templateData) string {
+	if t == nil || t.Local == nil {
+		return ""
+	}
+	var c []string
+	if t.Local.BackToBack > 0 {
+		c = append(c, fmt.Sprintf("B2B x%d", t.Local.BackToBack))
+	}
+	if t.Local.Combo > 0 {
+		c = append(c, fmt.Sprintf("Combo %d", t.Local.Combo))
+	}
+	return strings.Join(c, "  ")
+}
+
+func timer(t *templateData) string {
+	// timer() shows the elapsed time in Sprint and the countdown in Ultra.
+	if t == nil || t.Local == nil {
+		return ""
+	}
+	switch t.Local.Mode {
+	case tetris.Sprint:
+		return formatTime(t.Local.Time)
+	case tetris.Ultra:
+		return formatTime(t.Local.TimeLeft())
+	}
+	return ""
+}
+
+func formatTime(d time.Duration) string {
+	// formatTime() formats a duration as m:ss.mmm
+	return fmt.Sprintf("%d:%02d.%03d", d/time.Minute, d%time.Minute/time.Second, d%time.Second/time.Millisecond)
+}
+
+func center(s string, width int) string {
+	pad := max(width-len(s), 0)
+	return strings.Repeat(" ", pad/2) + s + strings.Repeat(" ", pad-pad/2)
+}
+
+func pieceRows(tm *tetris.Tetromino) []string {
+	// pieceRows renders the first two rows of a tetromino's grid in its
+	// spawn orientation, which is all we need to preview any shape.
 	var rendered []string
 	for i := range 2 {
 		row := []string{"  ", "  ", "  ", "  "}
-		if t != nil && t.Local != nil {
-			for iv, v := range t.Local.NexTetromino.Grid[i] {
+		if tm != nil {
+			for iv, v := range tm.Grid[i] {
 				if v {
-					row[iv] = fmt.Sprintf("\x1b[7m\x1b[%sm[]\x1b[0m", colorMap[t.Local.NexTetromino.Shape])
+					row[iv] = fmt.Sprintf("\x1b[7m\x1b[%sm[]\x1b[0m", colorMap[tm.Shape])
 				}
 			}
 		}
@@ -202,78 +437,326 @@ func nextPiece(t *templateData) []string {
 	return rendered
 }
 
-func stack2Proto(t *tetris.Tetris) *pb.Stack {
-	rendered := pb.Stack_builder{Rows: make([]*pb.Row, 20)}.Build()
+func localMeter(t *templateData, row int) string {
+	var pending int
+	if t != nil && t.Local != nil {
+		pending = t.Local.GarbagePending
+	}
+	return meter(pending, row)
+}
+
+func remoteMeter(t *templateData, row int) string {
+	return meter(int(t.Remote.GetGarbagePending()), row)
+}
 
-	for i := range rendered.GetRows() {
-		rendered.GetRows()[i] = pb.Row_builder{
-			Cells: make([]string, 10),
-		}.Build()
+func meter(pending, row int) string {
+	// meter() renders the side of the stack as a meter of the pending
+	// garbage, one red row per line from the bottom.
+	if 19-row < pending {
+		return "\x1b[31m|\x1b[0m"
 	}
+	return "|"
+}
 
-	for iy, y := range t.Stack {
-		for ix, x := range y {
-			if x != tetris.Shape("") {
-				rendered.GetRows()[iy].GetCells()[ix] = string(x)
+func proto2Game(gm *pb.GameMessage) *tetris.Tetris {
+	// proto2Game() turns the message of a player into a game to render
+	// as the local one. the falling tetromino is already in the stack.
+	t := &tetris.Tetris{
+		Stack:          make([][]tetris.Shape, 20),
+		GameOver:       gm.GetIsGameOver(),
+		LinesClear:     int(gm.GetLinesClear()),
+		Score:          int(gm.GetScore()),
+		GarbageSent:    int(gm.GetGarbageSent()),
+		GarbagePending: int(gm.GetGarbagePending()),
+	}
+	rows := gm.GetStack().GetRows()
+	for y := range t.Stack {
+		t.Stack[y] = make([]tetris.Shape, 10)
+		if y < len(rows) {
+			for x, c := range rows[y].GetCells() {
+				t.Stack[y][x] = tetris.Shape(c)
 			}
 		}
 	}
+	return t
+}
 
-	// renders the current tetromino if exist
-	if t.Tetromino != nil {
-		for iy, y := range t.Tetromino.Grid {
-			for ix, x := range y {
-				if x {
-					rendered.GetRows()[t.Tetromino.Y-iy].GetCells()[t.Tetromino.X+ix] = string(t.Tetromino.Shape)
-				}
+func remoteName(t *templateData) string { return t.Remote.GetName() }
+
+func remoteLinesClear(t *templateData) int32 { return t.Remote.GetLinesClear() }
+
+func remoteScore(t *templateData) int32 { return t.Remote.GetScore() }
+
+func battleStatus(t *templateData) string {
+	playing := 0
+	for _, o := range t.Opponents {
+		if !o.GetIsGameOver() {
+			playing++
+		}
+	}
+	return center(fmt.Sprintf("%.9s vs %d of %d", t.Name, playing, len(t.Opponents)), 36)
+}
+
+func target(t *templateData) string { return targetNames[t.Target] }
+
+func opponents(t *templateData) [23]string {
+	// opponents() renders the lines along the layout of the mini boards of
+	// the opponents, in two rows under their names. the lines along the
+	// stack start after its border, which is the left side of the boards.
+	var lines [23]string
+	var opps []*pb.GameMessage
+	if t != nil {
+		opps = t.Opponents
+	}
+	top, bottom := opps[:min(len(opps), miniBoards)], opps[min(len(opps), miniBoards):]
+	lines[0] = "+" + miniBoardsBorder(top, len(top))
+	lines[miniBoardLines+1] = miniBoardsBorder(bottom, len(top))
+	for l := range miniBoardLines {
+		lines[l+1] = miniBoardsLine(top, l)
+		lines[l+miniBoardLines+2] = miniBoardsLine(bottom, l)
+	}
+	if len(bottom) > 0 {
+		// the last line of the bottom boards is along the bottom border of the stack.
+		lines[21] = "|" + lines[21]
+		lines[22] = "+" + miniBoardsBorder(nil, len(bottom))
+	}
+	for i := range lines {
+		// clears what's left of the remote stack of the multiplayer layout.
+		lines[i] += "\033[K"
+	}
+	return lines
+}
+
+func miniBoardsBorder(opps []*pb.GameMessage, boards int) string {
+	// miniBoardsBorder() renders the top border of the boards with the names
+	// of the opponents, which are knocked out once they're out of the match.
+	var b strings.Builder
+	for i := range boards {
+		var name string
+		if i < len(opps) {
+			name = opps[i].GetName()
+			if opps[i].GetIsGameOver() {
+				name = "KO " + name
 			}
 		}
+		name = string([]rune(name)[:min(len([]rune(name)), miniBoardWidth)])
+		b.WriteString(name + strings.Repeat("-", miniBoardWidth-len([]rune(name))) + "+")
 	}
-	return rendered
+	return b.String()
 }
 
-func remoteName(t *templateData) string { return t.Remote.GetName() }
+func miniBoardsLine(opps []*pb.GameMessage, line int) string {
+	// miniBoardsLine() renders a line of the boards, each character being
+	// two rows of the stack of the opponent.
+	var b strings.Builder
+	for _, o := range opps {
+		for x := range miniBoardWidth {
+			top := miniBoardColor(o, 19-line*2, x)
+			bottom := miniBoardColor(o, 18-line*2, x)
+			switch {
+			case top == "" && bottom == "":
+				b.WriteString(" ")
+			case bottom == "":
+				b.WriteString("\x1b[" + top + "m▀\x1b[0m")
+			case top == "":
+				b.WriteString("\x1b[" + bottom + "m▄\x1b[0m")
+			case top == bottom:
+				b.WriteString("\x1b[" + top + "m█\x1b[0m")
+			default:
+				b.WriteString("\x1b[" + top + ";" + background(bottom) + "m▀\x1b[0m")
+			}
+		}
+		b.WriteString("|")
+	}
+	return b.String()
+}
 
-func remoteLinesClear(t *templateData) int32 { return t.Remote.GetLinesClear() }
+func miniBoardColor(o *pb.GameMessage, y, x int) string {
+	rows := o.GetStack().GetRows()
+	if y >= len(rows) || x >= len(rows[y].GetCells()) {
+		return ""
+	}
+	c, ok := colorMap[tetris.Shape(rows[y].GetCells()[x])]
+	switch {
+	case !ok:
+		return ""
+	case o.GetIsGameOver():
+		return Gray
+	}
+	return c
+}
+
+func background(c string) string {
+	// background() turns a foreground color into the same background color.
+	switch {
+	case strings.HasPrefix(c, "38"):
+		return "48" + c[2:]
+	case strings.HasPrefix(c, "9"):
+		return "10" + c[1:]
+	}
+	return "4" + c[1:]
+}
 
 func defaultLobby() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|      Welcome to Terminal Tetris      |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|      Welcome to Terminal Tetris      |"+menu)
 	}
 }
 
 func gameOver() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|             Game Over :)             |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|             Game Over :)             |"+menu)
 	}
 }
 
 func youWon() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|              You Won :)              |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|              You Won :)              |"+menu)
+	}
+}
+
+func pausedGame() msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|                Paused                |\033[13;9H|          (p) or esc to resume        |")
+	}
+}
+
+func replayFinished() msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|            End of replay             |\033[13;9H|                (q)uit                |")
+	}
+}
+
+func replayStatus(speed float64, paused bool) msgSetter {
+	return func(w io.Writer) {
+		state := "playing"
+		if paused {
+			state = "paused"
+		}
+		fmt.Fprintf(w, "Replay %gx %-7s  (p)ause  (s)lower  (f)aster  (n)ext piece  (q)uit", speed, state)
+	}
+}
+
+func botDemo() msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "Bot demo  (q)uit")
 	}
 }
 
+func cpuDifficulty() msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("Play vs CPU", 38)+"|\033[13;9H|"+center("(e)asy  (m)edium  (h)ard  (b)ack", 38)+"|")
+	}
+}
+
+func botFinished(score int) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("The bot scored %d", score), 38)+"|"+menu)
+	}
+}
+
+func levelUp(level int) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprintf(w, "Level up! level %d", level)
+	}
+}
+
+func noStatus() msgSetter {
+	return func(io.Writer) {}
+}
+
 func waitingOpponent() msgSetter {
 	return func(w io.Writer) {
 		fmt.Fprint(w, "\033[11;9H|       waiting for opponent...        |\033[13;9H|               (c)ancel               |")
 	}
 }
 
+func onlineMenu() msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("Play online", 38)+"|\033[13;9H|"+center("(m)atch  (n)ew room  (j)oin  (b)ack", 38)+"|")
+	}
+}
+
+func roomPrompt(code string) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("room code: "+fmt.Sprintf("%-12s", code), 38)+"|\033[13;9H|"+center("enter to join, esc to go back", 38)+"|")
+	}
+}
+
+func waitingRoom(code string) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("waiting in room "+code+"...", 38)+"|\033[13;9H|               (c)ancel               |")
+	}
+}
+
+func knockedOut(place int) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("Knocked out! You placed #%d", place), 38)+"|\033[13;9H|"+center("watching the match  (q)uit", 38)+"|")
+	}
+}
+
+func matchOver(winner string) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("%.20s won the match", winner), 38)+"|"+menu)
+	}
+}
+
+func spectateOver(msg string) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(msg, 38)+"|\033[13;9H|                (q)uit                |")
+	}
+}
+
 func waitingOpponentError() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|   there is no one to play with :(    |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|   there is no one to play with :(    |"+menu)
+	}
+}
+
+func roomNotFound(code string) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("there is no room "+code+" :(", 38)+"|"+menu)
 	}
 }
 
 func opponentLeft() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|  opponent left the game ¯\\_(ツ)_/¯   |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|  opponent left the game ¯\\_(ツ)_/¯   |"+menu)
 	}
 }
 
 func errorMessage() msgSetter {
 	return func(w io.Writer) {
-		fmt.Fprint(w, "\033[11;9H|      oops! something went wrong      |\033[13;9H|      (p)lay   (o)nline   (q)uit      |")
+		fmt.Fprint(w, "\033[11;9H|      oops! something went wrong      |"+menu)
+	}
+}
+
+func sprintFinished(d time.Duration) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center("40 lines in "+formatTime(d), 38)+"|"+menu)
+	}
+}
+
+func ultraFinished(score int, timeUp bool) msgSetter {
+	// ultraFinished() shows the score of an Ultra game, either when the
+	// time is up or when it tops out before.
+	end := "Time's up!"
+	if !timeUp {
+		end = "Game over!"
+	}
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("%s Score: %d", end, score), 38)+"|"+menu)
+	}
+}
+
+func marathonFinished(score int) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("All levels cleared! Score: %d", score), 38)+"|"+menu)
+	}
+}
+
+func settings(level int, goal tetris.Goal) msgSetter {
+	return func(w io.Writer) {
+		fmt.Fprint(w, "\033[11;9H|"+center(fmt.Sprintf("start level: %d  goal: %s", level, goal), 38)+"|"+menu)
 	}
 }
@@ -1,12 +1,16 @@
 package client
 
 import (
+	"fmt"
 	"log/slog"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"tetris/message"
 	"tetris/pb"
 	"tetris/tetris"
+	"time"
 
 	approvals "github.com/approvals/go-approval-tests"
 	"google.golang.org/protobuf/proto"
@@ -31,7 +35,7 @@ func TestRender(t *testing.T) {
 				tts := tetris.NewTestTetris(tetris.T)
 				r.multiPlayer(&mpData{
 					remote: pb.GameMessage_builder{
-						Stack:      stack2Proto(tts),
+						Stack:      message.Stack(tts),
 						LinesClear: proto.Int32(int32(tts.LinesClear)), //nolint:gosec
 						Name:       proto.String("remote"),
 					}.Build(),
@@ -39,6 +43,60 @@ func TestRender(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "multiplayer with pending garbage renders the meters",
+			do: func(r *render) {
+				tts := tetris.NewTestTetris(tetris.T)
+				tts.Stack[0] = []tetris.Shape{"", tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage}
+				tts.GarbagePending = 3
+				tts.Tetromino = nil
+				rts := tetris.NewTestTetris(tetris.I)
+				rts.GarbagePending = 5
+				r.multiPlayer(&mpData{
+					remote: message.FromGame("remote", rts),
+					local:  tts,
+				})
+			},
+		},
+		{
+			name: "battle with mini boards of the opponents",
+			do: func(r *render) {
+				tts := tetris.NewTestTetris(tetris.T)
+				var opponents []*pb.GameMessage
+				for i, s := range []tetris.Shape{tetris.I, tetris.J, tetris.L, tetris.O, tetris.S} {
+					ots := tetris.NewTestTetris(s)
+					ots.Stack[0] = []tetris.Shape{"", tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage, tetris.Garbage}
+					gm := message.FromGame(fmt.Sprintf("opponent%d", i+1), ots)
+					gm.SetIsGameOver(i == 1)
+					opponents = append(opponents, gm)
+				}
+				r.multiPlayer(&mpData{local: tts, opponents: opponents, target: pb.Target_TARGET_KO})
+			},
+		},
+		{
+			name: "spectator renders a player as the local game",
+			do: func(r *render) {
+				p1 := tetris.NewTestTetris(tetris.T)
+				p1.Score = 300
+				r.multiPlayer(&mpData{
+					local:  proto2Game(message.FromGame("player1", p1)),
+					remote: message.FromGame("player2", tetris.NewTestTetris(tetris.I)),
+					name:   "player1",
+				})
+			},
+		},
+		{
+			name: "spectate over lobby message",
+			do:   func(r *render) { r.lobby(spectateOver("player1 won the match")) },
+		},
+		{
+			name: "knocked out lobby message",
+			do:   func(r *render) { r.lobby(knockedOut(3)) },
+		},
+		{
+			name: "match over lobby message",
+			do:   func(r *render) { r.lobby(matchOver("winner")) },
+		},
 		{
 			name: "default lobby message",
 			do:   func(r *render) { r.lobby(defaultLobby()) },
@@ -51,6 +109,88 @@ func TestRender(t *testing.T) {
 			name: "you won lobby message",
 			do:   func(r *render) { r.lobby(youWon()) },
 		},
+		{
+			name: "sprint finished lobby message",
+			do:   func(r *render) { r.lobby(sprintFinished(83456 * time.Millisecond)) },
+		},
+		{
+			name: "single player sprint renders the timer",
+			do: func(r *render) {
+				tts := tetris.NewTestTetris(tetris.T)
+				tts.Mode, tts.Time = tetris.Sprint, 61005*time.Millisecond
+				r.singlePlayer(tts)
+			},
+		},
+		{
+			name: "ultra finished lobby message",
+			do:   func(r *render) { r.lobby(ultraFinished(12345, true)) },
+		},
+		{
+			name: "ultra topped out lobby message",
+			do:   func(r *render) { r.lobby(ultraFinished(12345, false)) },
+		},
+		{
+			name: "single player ultra renders the countdown",
+			do: func(r *render) {
+				tts := tetris.NewTestTetris(tetris.T)
+				tts.Mode, tts.Time = tetris.Ultra, 61005*time.Millisecond
+				r.singlePlayer(tts)
+			},
+		},
+		{
+			name: "marathon finished lobby message",
+			do:   func(r *render) { r.lobby(marathonFinished(123456)) },
+		},
+		{
+			name: "settings lobby message",
+			do:   func(r *render) { r.lobby(settings(5, tetris.VariableGoal)) },
+		},
+		{
+			name: "paused lobby message",
+			do:   func(r *render) { r.lobby(pausedGame()) },
+		},
+		{
+			name: "paused single player hides the board",
+			do: func(r *render) {
+				tts := tetris.NewTestTetris(tetris.T)
+				tts.HoldTetromino = tetris.NewTestTetris(tetris.I).Tetromino
+				tts.Stack[0][0] = tetris.J
+				tts.Paused = true
+				r.singlePlayer(tts)
+			},
+		},
+		{
+			name: "replay finished lobby message",
+			do:   func(r *render) { r.lobby(replayFinished()) },
+		},
+		{
+			name: "paused replay status",
+			do:   func(r *render) { r.status(replayStatus(0.5, true)) },
+		},
+		{
+			name: "bot demo status",
+			do:   func(r *render) { r.status(botDemo()) },
+		},
+		{
+			name: "cpu difficulty lobby message",
+			do:   func(r *render) { r.lobby(cpuDifficulty()) },
+		},
+		{
+			name: "bot finished lobby message",
+			do:   func(r *render) { r.lobby(botFinished(12345)) },
+		},
+		{
+			name: "online menu lobby message",
+			do:   func(r *render) { r.lobby(onlineMenu()) },
+		},
+		{
+			name: "room prompt lobby message",
+			do:   func(r *render) { r.lobby(roomPrompt("AB3")) },
+		},
+		{
+			name: "waiting room lobby message",
+			do:   func(r *render) { r.lobby(waitingRoom("K7QX")) },
+		},
 		{
 			name: "waiting opponent lobby message",
 			do:   func(r *render) { r.lobby(waitingOpponent()) },
@@ -59,6 +199,10 @@ func TestRender(t *testing.T) {
 			name: "waiting opponent error message",
 			do:   func(r *render) { r.lobby(waitingOpponentError()) },
 		},
+		{
+			name: "room not found lobby message",
+			do:   func(r *render) { r.lobby(roomNotFound("WXYZ")) },
+		},
 		{
 			name: "opponent left the game message",
 			do:   func(r *render) { r.lobby(opponentLeft()) },
@@ -121,12 +265,73 @@ func TestLocalStack(t *testing.T) {
 			t.Errorf("want %v, got %v", want, got)
 		}
 	})
+
+	t.Run("localStack hides the rows of the line clear animation", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		te.Tetromino = nil
+		te.Stack[0][0], te.Stack[1][0] = tetris.J, tetris.J
+		got := localStack(&templateData{Local: te, Hidden: []int{0}})
+		if got[19][0] != "  " || got[18][0] == "  " {
+			t.Errorf("wanted only row 0 to be hidden, got %q and %q", got[19][0], got[18][0])
+		}
+	})
+}
+
+func TestLineClearAnimation(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.J)
+	te.Tetromino = nil
+	te.LinesCleared = &tetris.LinesCleared{Rows: []int{0}}
+	w := &writeCounter{}
+	r := &render{
+		writer:       w,
+		logger:       slog.Default(),
+		template:     loadTemplate(),
+		templateData: &templateData{Name: "local"},
+	}
+	r.singlePlayer(te)
+	r.linesCleared()
+	time.Sleep(lineClearFrameTime * 3)
+
+	// the lines are removed by the game, which ends the animation.
+	r.singlePlayer(tetris.NewTestTetris(tetris.J))
+	frames := w.count()
+	time.Sleep(lineClearFrameTime * 2)
+	if frames < 3 {
+		t.Errorf("wanted the animation to render frames, got %d", frames)
+	}
+	if w.count() != frames {
+		t.Errorf("wanted the animation to stop once the lines are removed")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Hidden != nil {
+		t.Errorf("wanted no hidden rows after the animation, got %v", r.Hidden)
+	}
+}
+
+// writeCounter counts the writes of the renderer and its animation.
+type writeCounter struct {
+	mu     sync.Mutex
+	writes int
+}
+
+func (b *writeCounter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writes++
+	return len(p), nil
+}
+
+func (b *writeCounter) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writes
 }
 
 func TestRemoteStack(t *testing.T) {
 	td := &templateData{
 		Remote: pb.GameMessage_builder{
-			Stack: stack2Proto(tetris.NewTestTetris(tetris.J)),
+			Stack: message.Stack(tetris.NewTestTetris(tetris.J)),
 		}.Build(),
 	}
 	want := [20][10]string{}
@@ -159,48 +364,134 @@ func TestRemoteStack(t *testing.T) {
 	})
 }
 
-func TestNextPiece(t *testing.T) {
+func TestNextQueue(t *testing.T) {
+	j := []string{"\x1b[7m\x1b[34m[]\x1b[0m      ", "\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m  "}
+	o := []string{"\x1b[7m\x1b[33m[]\x1b[0m\x1b[7m\x1b[33m[]\x1b[0m    ", "\x1b[7m\x1b[33m[]\x1b[0m\x1b[7m\x1b[33m[]\x1b[0m    "}
+	i := []string{"        ", "\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m"}
+	empty := func() [20]string {
+		var e [20]string
+		for i := range e {
+			e[i] = "        "
+		}
+		e[1] = "Next:   "
+		return e
+	}
+
+	t.Run("renders every preview stacked vertically", func(t *testing.T) {
+		td := &templateData{Local: tetris.NewTestTetris(tetris.J)}
+		td.Local.Next = []*tetris.Tetromino{
+			tetris.NewTestTetris(tetris.J).Tetromino,
+			tetris.NewTestTetris(tetris.O).Tetromino,
+			tetris.NewTestTetris(tetris.I).Tetromino,
+		}
+		want := empty()
+		want[2], want[3] = j[0], j[1]
+		want[5], want[6] = o[0], o[1]
+		want[8], want[9] = i[0], i[1]
+		if got := nextQueue(td, 2); !reflect.DeepEqual(want, got) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("renders up to six previews", func(t *testing.T) {
+		td := &templateData{Local: tetris.NewTestTetris(tetris.O)}
+		for range 7 {
+			td.Local.Next = append(td.Local.Next, tetris.NewTestTetris(tetris.O).Tetromino)
+		}
+		want := empty()
+		for p := range 6 {
+			want[2+p*3], want[3+p*3] = o[0], o[1]
+		}
+		if got := nextQueue(td, 2); !reflect.DeepEqual(want, got) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("nextQueue with nil tetris returns emtpy spaces", func(t *testing.T) {
+		if got := nextQueue(nil, 2); !reflect.DeepEqual(empty(), got) {
+			t.Errorf("want %q, got %q", empty(), got)
+		}
+	})
+}
+
+func TestHoldPiece(t *testing.T) {
+	td := &templateData{Local: tetris.NewTestTetris(tetris.J)}
+	want := []string{"        ", "        "}
+	if got := holdPiece(td); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	td.Local.HoldTetromino = td.Local.Next[0]
+	want = []string{"\x1b[7m\x1b[34m[]\x1b[0m      ", "\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m  "}
+	if got := holdPiece(td); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestProto2Game(t *testing.T) {
+	tts := tetris.NewTestTetris(tetris.J)
+	tts.Score, tts.LinesClear, tts.GarbagePending = 1200, 8, 3
+	got := proto2Game(message.FromGame("remote", tts))
+	if got.Score != 1200 || got.LinesClear != 8 || got.GarbagePending != 3 {
+		t.Errorf("wanted the score, lines and pending garbage of the game, got %d, %d and %d", got.Score, got.LinesClear, got.GarbagePending)
+	}
+	if got.Stack[19][3] != tetris.J || got.Stack[18][5] != tetris.J || got.Tetromino != nil {
+		t.Errorf("wanted the falling tetromino in the stack, got %v", got.Stack[18:])
+	}
+}
+
+func TestLastClear(t *testing.T) {
+	if got := lastClear(nil); got != "" {
+		t.Errorf("want empty string, got %q", got)
+	}
+	td := &templateData{Local: tetris.NewTestTetris(tetris.T)}
+	td.Local.LastClear = tetris.Clear{Lines: 2, TSpin: tetris.TSpinFull}
+	if got := lastClear(td); got != "T-Spin Double" {
+		t.Errorf("want %q, got %q", "T-Spin Double", got)
+	}
+}
+
+func TestChain(t *testing.T) {
 	tests := []struct {
-		shape tetris.Shape
-		want  []string
+		name              string
+		backToBack, combo int
+		want              string
 	}{
-		{tetris.J, []string{"\x1b[7m\x1b[34m[]\x1b[0m      ", "\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m\x1b[7m\x1b[34m[]\x1b[0m  "}},
-		{tetris.O, []string{"\x1b[7m\x1b[33m[]\x1b[0m\x1b[7m\x1b[33m[]\x1b[0m    ", "\x1b[7m\x1b[33m[]\x1b[0m\x1b[7m\x1b[33m[]\x1b[0m    "}},
-		{tetris.I, []string{"        ", "\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m\x1b[7m\x1b[36m[]\x1b[0m"}},
+		{"no chain", 0, 0, ""},
+		{"back-to-back", 3, 0, "B2B x3"},
+		{"combo", 0, 5, "Combo 5"},
+		{"back-to-back and combo", 1, 2, "B2B x1  Combo 2"},
 	}
 	for _, tt := range tests {
-		t.Run(string(tt.shape), func(t *testing.T) {
-			td := &templateData{Local: tetris.NewTestTetris(tt.shape)}
-			got := nextPiece(td)
-			if !reflect.DeepEqual(tt.want, got) {
-				t.Errorf("want %v, got %v", tt.want, got)
+		t.Run(tt.name, func(t *testing.T) {
+			td := &templateData{Local: tetris.NewTestTetris(tetris.T)}
+			td.Local.BackToBack = tt.backToBack
+			td.Local.Combo = tt.combo
+			if got := chain(td); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
 			}
 		})
 	}
-	t.Run("nextPiece with nil tetris returns emtpy spaces", func(t *testing.T) {
-		want := []string{"        ", "        "}
-		got := nextPiece(nil)
-		if !reflect.DeepEqual(got, want) {
-			t.Errorf("want %v, got %v", want, got)
-		}
-	})
+	if got := chain(nil); got != "" {
+		t.Errorf("want empty string, got %q", got)
+	}
 }
 
-func TestStack2Proto(t *testing.T) {
-	got := stack2Proto(tetris.NewTestTetris(tetris.J))
-	want := pb.Stack_builder{Rows: make([]*pb.Row, 20)}.Build()
-
-	for i := range want.GetRows() {
-		want.GetRows()[i] = pb.Row_builder{
-			Cells: make([]string, 10),
-		}.Build()
+func TestFlash(t *testing.T) {
+	b := &strings.Builder{}
+	r := &render{writer: b, templateData: &templateData{}}
+	r.status(botDemo())
+	r.flash(levelUp(2), lineClearFrameTime)
+	r.mu.Lock()
+	if !strings.HasSuffix(b.String(), "Level up! level 2") {
+		t.Errorf("wanted the level up below the game, got %q", b.String())
 	}
-	want.GetRows()[19].GetCells()[3] = "J"
-	want.GetRows()[18].GetCells()[3] = "J"
-	want.GetRows()[18].GetCells()[4] = "J"
-	want.GetRows()[18].GetCells()[5] = "J"
+	r.mu.Unlock()
 
-	if !reflect.DeepEqual(want, got) {
-		t.Errorf("want %v, got %v", want, got)
+	// the status replaced is back after the flash.
+	time.Sleep(lineClearFrameTime * 2)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !strings.HasSuffix(b.String(), "Bot demo  (q)uit") {
+		t.Errorf("wanted the status back after the flash, got %q", b.String())
 	}
 }
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"tetris/tetris"
+	"time"
+)
+
+func (c *Client) saveReplay() {
+	// saveReplay() writes the replay of the last game into the replays
+	// directory, named after the time it was saved and the game mode.
+	if c.options == nil || c.options.ReplayDir == "" {
+		return
+	}
+	r := c.tetris.Replay()
+	if r == nil {
+		return
+	}
+	if err := os.MkdirAll(c.options.ReplayDir, 0o750); err != nil {
+		c.logger.Error("unable to create replays directory", slog.String("error", err.Error()))
+		return
+	}
+	// the random part of the name keeps replays saved in the same second
+	// apart.
+	f, err := os.CreateTemp(c.options.ReplayDir, fmt.Sprintf("%s-*-%s.json", time.Now().Format("20060102-150405"), r.Mode))
+	if err != nil {
+		c.logger.Error("unable to create replay file", slog.String("error", err.Error()))
+		return
+	}
+	defer f.Close() //nolint: errcheck
+	name := f.Name()
+	if err := r.Write(f); err != nil {
+		c.logger.Error("unable to write replay file", slog.String("error", err.Error()))
+		return
+	}
+	c.logger.Debug("replay saved", slog.String("file", name))
+}
+
+// replaySpeeds are the speeds a replay can be played back at.
+var replaySpeeds = []float64{0.5, 1, 2, 4}
+
+// replayTicker is a tetris.Ticker that only ticks when the replay does.
+type replayTicker struct {
+	ch chan time.Time
+}
+
+func (r *replayTicker) C() <-chan time.Time { return r.ch }
+func (r *replayTicker) Stop()               {}
+func (r *replayTicker) Reset(time.Duration) {}
+
+// replayer plays back a tetris.Replay into the game of the client.
+type replayer struct {
+	replay *tetris.Replay
+	ticker *replayTicker
+	ctlCh  chan rune
+	// elapsed is the time of the last event played back, which is the
+	// time of the game.
+	elapsed atomic.Int64
+	start   time.Time
+	speed   int
+	paused  bool
+}
+
+func newReplayer(r *tetris.Replay) (*replayer, tetrisGame) {
+	rp := &replayer{
+		replay: r,
+		ticker: &replayTicker{ch: make(chan time.Time)},
+		ctlCh:  make(chan rune),
+		start:  time.Now(),
+		speed:  1,
+	}
+	opts := append(r.Options(), tetris.WithTicker(rp.ticker), tetris.WithTimeSource(rp.now))
+	return rp, tetris.NewGame(opts...)
+}
+
+func (r *replayer) now() time.Time {
+	return r.start.Add(time.Duration(r.elapsed.Load()))
+}
+
+func (c *Client) listenReplay() {
+	// listenReplay() plays back the replay event by event, waiting the
+	// time between events at the current speed, while the updates of
+	// the game are rendered as in any single player game.
+	r := c.replayer
+	var last *tetris.Tetris
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case u := <-c.tetris.GetUpdate():
+				evs := c.events.pop()
+				last = u
+				c.render.singlePlayer(u)
+				c.handleEvents(evs)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	end := func() {
+		// end() stops the game and waits for its last update to be
+		// rendered, for the end of the replay to be rendered over it.
+		c.tetris.Stop()
+		close(stop)
+		<-stopped
+	}
+	c.tetris.Start()
+	c.render.status(replayStatus(replaySpeeds[r.speed], r.paused))
+
+	var prev time.Duration
+	var stepping bool
+	for _, e := range r.replay.Events {
+		if !stepping {
+			stepping = r.wait(e.Time-prev, c.render)
+		}
+		prev = e.Time
+		r.elapsed.Store(int64(e.Time))
+		switch e.Kind {
+		case tetris.ReplayAction:
+			c.tetris.Action(e.Action)
+		case tetris.ReplayTick:
+			r.ticker.ch <- r.now()
+		case tetris.ReplayGarbage:
+			c.tetris.RemoteGarbage(e.Garbage)
+		case tetris.ReplaySpawn:
+			// stepping plays the events up to the next tetromino.
+			stepping = false
+		case tetris.ReplayTimeUp:
+			end()
+			var score int
+			if last != nil {
+				score = last.Score
+			}
+			c.render.lobby(ultraFinished(score, true))
+			return
+		}
+	}
+	end()
+	c.render.lobby(replayFinished())
+}
+
+func (r *replayer) wait(d time.Duration, rd renderer) bool {
+	// wait() waits for d at the current speed, handling the controls of
+	// the replay meanwhile. it returns true when stepping to the next
+	// tetromino, which can only be done while paused.
+	timer := time.NewTimer(time.Duration(float64(d) / replaySpeeds[r.speed]))
+	defer timer.Stop()
+	for {
+		var timerCh <-chan time.Time
+		if !r.paused {
+			timerCh = timer.C
+		}
+		select {
+		case <-timerCh:
+			return false
+		case k := <-r.ctlCh:
+			switch k {
+			case 'p':
+				r.paused = !r.paused
+			case 's':
+				r.speed = max(r.speed-1, 0)
+			case 'f':
+				r.speed = min(r.speed+1, len(replaySpeeds)-1)
+			case 'n':
+				if r.paused {
+					return true
+				}
+			}
+			rd.status(replayStatus(replaySpeeds[r.speed], r.paused))
+		}
+	}
+}
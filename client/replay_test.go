@@ -0,0 +1,111 @@
+package client
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"tetris/tetris"
+	"time"
+)
+
+func TestListenReplay(t *testing.T) {
+	newClient := func(r *tetris.Replay) (*Client, *mockRender) {
+		render := &mockRender{}
+		cl := &Client{
+			render: render,
+			logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			state:  &state{current: replaying},
+		}
+		cl.replayer, cl.tetris = newReplayer(r)
+		return cl, render
+	}
+	play := func(t *testing.T, cl *Client) {
+		t.Helper()
+		done := make(chan struct{})
+		go func() { cl.listenReplay(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the replay to finish")
+		}
+	}
+
+	t.Run("the replay plays back the game", func(t *testing.T) {
+		cl, render := newClient(&tetris.Replay{
+			Seed:       1,
+			Randomizer: tetris.Bag7,
+			Previews:   3,
+			Mode:       tetris.Marathon,
+			StartLevel: 1,
+			Goal:       tetris.FixedGoal,
+			Events: []tetris.ReplayEvent{
+				{Kind: tetris.ReplaySpawn},
+				{Time: 10 * time.Millisecond, Kind: tetris.ReplayAction, Action: tetris.MoveLeft},
+				{Time: 20 * time.Millisecond, Kind: tetris.ReplayTick},
+				{Time: 30 * time.Millisecond, Kind: tetris.ReplayAction, Action: tetris.DropDown},
+				{Time: 30 * time.Millisecond, Kind: tetris.ReplaySpawn},
+				{Time: 40 * time.Millisecond, Kind: tetris.ReplayAction, Action: tetris.MoveRight},
+			},
+		})
+		play(t, cl)
+		if render.lobbyCount != 1 {
+			t.Errorf("wanted the end of the replay in the lobby, got render.lobby() called %d times", render.lobbyCount)
+		}
+		var cells int
+		for _, row := range render.local.Stack {
+			for _, c := range row {
+				if c != "" {
+					cells++
+				}
+			}
+		}
+		if cells != 4 {
+			t.Errorf("wanted the dropped tetromino in the stack, got %d cells", cells)
+		}
+		// the game is stopped at the end of the replay, which can be
+		// before the update of its last event is rendered.
+		if render.local.Time < 30*time.Millisecond {
+			t.Errorf("wanted the time of the replay, got %v", render.local.Time)
+		}
+	})
+
+	t.Run("time up before any update ends the replay", func(t *testing.T) {
+		cl, render := newClient(&tetris.Replay{
+			Mode:   tetris.Ultra,
+			Events: []tetris.ReplayEvent{{Kind: tetris.ReplayTimeUp}},
+		})
+		play(t, cl)
+		if render.lobbyCount != 1 {
+			t.Errorf("wanted the end of the replay in the lobby, got render.lobby() called %d times", render.lobbyCount)
+		}
+	})
+}
+
+func TestReplayerControls(t *testing.T) {
+	r, _ := newReplayer(&tetris.Replay{})
+	render := &mockRender{}
+	stepped := make(chan bool)
+	go func() { stepped <- r.wait(time.Hour, render) }()
+
+	for _, k := range []rune{'f', 'f', 'f', 's', 'p', 'n'} {
+		r.ctlCh <- k
+	}
+	if !<-stepped {
+		t.Errorf("wanted to step to the next piece while paused")
+	}
+	if replaySpeeds[r.speed] != 2 {
+		t.Errorf("wanted speed 2x, got %gx", replaySpeeds[r.speed])
+	}
+	if !r.paused {
+		t.Errorf("wanted replay to stay paused after stepping")
+	}
+	if render.statusCount != 5 {
+		t.Errorf("wanted status to be rendered on every control, got %d", render.statusCount)
+	}
+
+	go func() { stepped <- r.wait(time.Millisecond, render) }()
+	r.ctlCh <- 'p'
+	if <-stepped {
+		t.Errorf("wanted to wait for the next event after resuming")
+	}
+}
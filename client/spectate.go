@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"tetris/pb"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const listGamesTimeout = 5 * time.Second
+
+// ListGames returns the online matches being played in the server at
+// address.
+func ListGames(address string) ([]*pb.GameInfo, error) {
+	conn, err := grpc.NewClient(address+serverPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gRPC client: %w", err)
+	}
+	defer conn.Close() //nolint: errcheck
+	ctx, cancel := context.WithTimeout(context.Background(), listGamesTimeout)
+	defer cancel()
+	res, err := pb.NewTetrisServiceClient(conn).ListGames(ctx, &pb.ListGamesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list games: %w", err)
+	}
+	return res.GetGames(), nil
+}
+
+func (c *Client) listenSpectate(id int32) {
+	// listenSpectate() watches an online match until it's over.
+	conn, err := grpc.NewClient(c.options.Address+serverPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		c.logger.Error("unable to create gRPC client", slog.String("error", err.Error()))
+		c.render.lobby(spectateOver("oops! something went wrong"))
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			c.logger.Error("unable to close gRPC client", slog.String("error", err.Error()))
+		}
+	}()
+	stream, err := pb.NewTetrisServiceClient(conn).Spectate(context.Background(), pb.SpectateRequest_builder{Game: proto.Int32(id)}.Build())
+	if err != nil {
+		c.logger.Error("unable to create gRPC Spectate stream", slog.String("error", err.Error()))
+		c.render.lobby(spectateOver("oops! something went wrong"))
+		return
+	}
+
+	// the first message has the size of the match.
+	var b *battle
+	for {
+		gm, err := stream.Recv()
+		switch {
+		case err == io.EOF && b != nil:
+			c.logger.Debug("spectate stream.Recv() closed with EOF")
+			if w := b.winner(); w != "" {
+				c.render.lobby(spectateOver(fmt.Sprintf("%.20s won the match", w)))
+			} else {
+				c.render.lobby(spectateOver("The match is over"))
+			}
+			return
+		case status.Code(err) == codes.NotFound:
+			c.logger.Debug("spectate stream.Recv() closed with NotFound", slog.String("msg", err.Error()))
+			c.render.lobby(spectateOver(fmt.Sprintf("game %d not found", id)))
+			return
+		case err != nil:
+			c.logger.Error("spectate stream.Recv() unable to receive message", slog.String("error", err.Error()))
+			c.render.lobby(spectateOver("oops! something went wrong"))
+			return
+		case b == nil:
+			b = newBattle(int(gm.GetPlayers()))
+			b.watching = true
+		default:
+			b.update(gm)
+			c.renderSpectate(b)
+		}
+	}
+}
+
+func (c *Client) renderSpectate(b *battle) {
+	// renderSpectate() renders the first player of the match as the local
+	// game and the rest as its opponents.
+	players := b.list()
+	first := players[0]
+	mpd := &mpData{local: proto2Game(first), name: first.GetName()}
+	switch {
+	case b.players > 2:
+		mpd.opponents, mpd.target = players[1:], first.GetTarget()
+	case len(players) > 1:
+		mpd.remote = players[1]
+	}
+	c.render.multiPlayer(mpd)
+}
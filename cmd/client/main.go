@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"tetris/client"
+	"tetris/tetris"
 )
 
 const VERSION = "v0.0.13"
@@ -17,26 +19,63 @@ const (
 	hideCursor = "\033[2J\033[?25l" // also clear screen
 	showCursor = "\n\033[22;0H\n\033[?25h"
 	logFile    = ".tetrisLog"
+	replayDir  = ".tetris/replays"
 
 	// Option Flags.
-	debugFlag   = "debug"
-	versionFlag = "version"
-	noGhostFlag = "noghost"
-	nameFlag    = "name"
-	addressFlag = "address"
+	debugFlag      = "debug"
+	versionFlag    = "version"
+	noGhostFlag    = "noghost"
+	nameFlag       = "name"
+	addressFlag    = "address"
+	seedFlag       = "seed"
+	randomizerFlag = "randomizer"
+	previewsFlag   = "previews"
+	levelFlag      = "level"
+	goalFlag       = "goal"
+	levelCapFlag   = "levelcap"
+	replayFlag     = "replay"
+	roomFlag       = "room"
+	playersFlag    = "players"
+	gamesFlag      = "games"
+	spectateFlag   = "spectate"
 )
 
 var (
-	debug, noGhost bool
-	name, address  string
+	debug, noGhost  bool
+	games           bool
+	spectate        int
+	name, address   string
+	room            string
+	seed            int64
+	previews, level int
+	levelCap        int
+	players         int
+	randomizer      = tetris.Bag7
+	goal            = tetris.FixedGoal
+	replay          *tetris.Replay
 )
 
 func main() {
 	evalOptions()
+	if games {
+		listGames()
+		return
+	}
 	c, err := client.New(initLogger(), &client.Options{
-		NoGhost: noGhost,
-		Address: address,
-		Name:    name,
+		NoGhost:    noGhost,
+		Address:    address,
+		Name:       name,
+		Seed:       seed,
+		Randomizer: randomizer,
+		Previews:   previews,
+		Level:      level,
+		Goal:       goal,
+		LevelCap:   levelCap,
+		ReplayDir:  replaysPath(),
+		Replay:     replay,
+		Room:       strings.ToUpper(room),
+		Players:    players,
+		Spectate:   int32(spectate), // nolint:gosec
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -63,17 +102,65 @@ func initLogger() *slog.Logger {
 	return slog.New(handler)
 }
 
+func replaysPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, replayDir)
+}
+
 func evalOptions() {
 	flag.BoolFunc(versionFlag, "Prints version", version)
 	flag.BoolVar(&debug, debugFlag, false, "Enables debugging into ~/.tetrisLog")
 	flag.BoolVar(&noGhost, noGhostFlag, false, "Disables Ghost Piece")
 	flag.StringVar(&name, nameFlag, "noName", "Current player's name")
 	flag.StringVar(&address, addressFlag, "127.0.0.1", "Tetris server address")
+	flag.StringVar(&room, roomFlag, "", "Code of a room created online to play with whoever joins it (default is anyone)")
+	flag.BoolVar(&games, gamesFlag, false, "Lists the online matches being played in the server")
+	flag.IntVar(&spectate, spectateFlag, 0, "Watches the online match with this id, see -games")
+	flag.IntVar(&players, playersFlag, 2, "Number of players of the online matches you start or are matched in (2-8)")
+	flag.Int64Var(&seed, seedFlag, 0, "Randomizer seed to replay a sequence of tetrominoes (0 is random)")
+	flag.IntVar(&previews, previewsFlag, 3, "Number of tetrominoes shown in the Next queue (1-6)")
+	flag.Func(randomizerFlag, fmt.Sprintf("Randomizer that deals the tetrominoes %v (default %q)", tetris.RandomizerKinds(), tetris.Bag7), func(s string) (err error) {
+		randomizer, err = tetris.ParseRandomizer(s)
+		return err
+	})
+	flag.IntVar(&level, levelFlag, 1, fmt.Sprintf("Level single player games start at (1-%d)", tetris.MaxStartLevel))
+	flag.Func(goalFlag, fmt.Sprintf("Leveling goal %v (default %q)", tetris.Goals(), tetris.FixedGoal), func(s string) (err error) {
+		goal, err = tetris.ParseGoal(s)
+		return err
+	})
+	flag.Func(replayFlag, "Plays back the replay file instead of playing", readReplay)
+	flag.IntVar(&levelCap, levelCapFlag, 0, "Last level of Marathon games, completing it wins the game (0 is endless)")
 	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
 
+func listGames() {
+	games, err := client.ListGames(address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(games) == 0 {
+		fmt.Println("no games being played")
+	}
+	for _, g := range games {
+		fmt.Printf("%d: %s (%d of %d playing)\n", g.GetId(), strings.Join(g.GetPlayers(), " vs "), g.GetPlaying(), len(g.GetPlayers()))
+	}
+}
+
+func readReplay(name string) error {
+	f, err := os.Open(name) //nolint: gosec
+	if err != nil {
+		return fmt.Errorf("unable to open replay: %w", err)
+	}
+	defer f.Close() //nolint: errcheck
+	replay, err = tetris.ReadReplay(f)
+	return err
+}
+
 func version(string) error {
 	fmt.Println(VERSION)
 	os.Exit(0)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net"
@@ -13,6 +14,9 @@ import (
 const port = ":9000"
 
 func main() {
+	authoritative := flag.Bool("authoritative", false, "Runs the games of the players on the server, so that clients only send their actions")
+	flag.Parse()
+
 	lis, err := net.Listen("tcp", port) //nolint:gosec
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
@@ -20,7 +24,11 @@ func main() {
 	defer lis.Close()
 	s := grpc.NewServer()
 	defer s.Stop()
-	pb.RegisterTetrisServiceServer(s, server.New())
+	var opts []server.Option
+	if *authoritative {
+		opts = append(opts, server.WithAuthority())
+	}
+	pb.RegisterTetrisServiceServer(s, server.New(opts...))
 
 	fmt.Printf("starting server in port %s...\n", port)
 	if err := s.Serve(lis); err != nil {
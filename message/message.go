@@ -0,0 +1,201 @@
+// Package message turns the games of the players into the messages the
+// client and the server send each other.
+package message
+
+import (
+	"tetris/pb"
+	"tetris/tetris"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// FromGame returns the message with the state of the game of a
+// player, as sent to the rest of the match.
+func FromGame(name string, t *tetris.Tetris) *pb.GameMessage {
+	return pb.GameMessage_builder{
+		Name:           proto.String(name),
+		IsGameOver:     proto.Bool(t.GameOver),
+		IsStarted:      proto.Bool(true),
+		LinesClear:     proto.Int32(int32(t.LinesClear)),     // nolint:gosec
+		Score:          proto.Int32(int32(t.Score)),          // nolint:gosec
+		GarbageSent:    proto.Int32(int32(t.GarbageSent)),    // nolint:gosec
+		GarbagePending: proto.Int32(int32(t.GarbagePending)), // nolint:gosec
+		Stack:          Stack(t),
+	}.Build()
+}
+
+// State returns the state of the game of a player, as the server sends
+// it to them in authoritative matches. It leaves out the seed, which
+// would tell them every tetromino and garbage hole to come.
+func State(t *tetris.Tetris) *pb.State {
+	next := make([]*pb.Piece, len(t.Next))
+	for i, n := range t.Next {
+		next[i] = piece(n)
+	}
+	var lc *pb.LinesCleared
+	if t.LinesCleared != nil {
+		r := make([]int32, len(t.LinesCleared.Rows))
+		for i, y := range t.LinesCleared.Rows {
+			r[i] = int32(y) // nolint:gosec
+		}
+		lc = pb.LinesCleared_builder{Rows: r, Clear: lineClear(t.LinesCleared.Clear)}.Build()
+	}
+	return pb.State_builder{
+		Stack:          rows(t.Stack),
+		Tetromino:      piece(t.Tetromino),
+		Next:           next,
+		Hold:           piece(t.HoldTetromino),
+		Level:          proto.Int32(int32(t.Level)),      // nolint:gosec
+		LinesClear:     proto.Int32(int32(t.LinesClear)), // nolint:gosec
+		Score:          proto.Int32(int32(t.Score)),      // nolint:gosec
+		Pieces:         proto.Int32(int32(t.Pieces)),     // nolint:gosec
+		LastClear:      lineClear(t.LastClear),
+		LinesCleared:   lc,
+		BackToBack:     proto.Int32(int32(t.BackToBack)),     // nolint:gosec
+		Combo:          proto.Int32(int32(t.Combo)),          // nolint:gosec
+		GarbageSent:    proto.Int32(int32(t.GarbageSent)),    // nolint:gosec
+		GarbagePending: proto.Int32(int32(t.GarbagePending)), // nolint:gosec
+		Mode:           proto.String(string(t.Mode)),
+		Time:           proto.Int64(t.Time.Milliseconds()),
+		Won:            proto.Bool(t.Won),
+		IsGameOver:     proto.Bool(t.GameOver),
+	}.Build()
+}
+
+// Game returns the game of a player from the state the server sends.
+func Game(s *pb.State) *tetris.Tetris {
+	t := &tetris.Tetris{
+		Stack:          make([][]tetris.Shape, len(s.GetStack().GetRows())),
+		Tetromino:      tetromino(s.GetTetromino()),
+		HoldTetromino:  tetromino(s.GetHold()),
+		Level:          int(s.GetLevel()),
+		LinesClear:     int(s.GetLinesClear()),
+		Score:          int(s.GetScore()),
+		Pieces:         int(s.GetPieces()),
+		LastClear:      fromClear(s.GetLastClear()),
+		BackToBack:     int(s.GetBackToBack()),
+		Combo:          int(s.GetCombo()),
+		GarbageSent:    int(s.GetGarbageSent()),
+		GarbagePending: int(s.GetGarbagePending()),
+		Mode:           tetris.Mode(s.GetMode()),
+		Time:           time.Duration(s.GetTime()) * time.Millisecond,
+		Won:            s.GetWon(),
+		GameOver:       s.GetIsGameOver(),
+	}
+	for i, r := range s.GetStack().GetRows() {
+		t.Stack[i] = make([]tetris.Shape, len(r.GetCells()))
+		for j, c := range r.GetCells() {
+			t.Stack[i][j] = tetris.Shape(c)
+		}
+	}
+	for _, n := range s.GetNext() {
+		t.Next = append(t.Next, tetromino(n))
+	}
+	if s.HasLinesCleared() {
+		lc := &tetris.LinesCleared{Clear: fromClear(s.GetLinesCleared().GetClear())}
+		for _, r := range s.GetLinesCleared().GetRows() {
+			lc.Rows = append(lc.Rows, int(r))
+		}
+		t.LinesCleared = lc
+	}
+	return t
+}
+
+func rows(stack [][]tetris.Shape) *pb.Stack {
+	// rows() returns the stack without the falling tetromino.
+	r := make([]*pb.Row, len(stack))
+	for i, y := range stack {
+		cells := make([]string, len(y))
+		for j, x := range y {
+			cells[j] = string(x)
+		}
+		r[i] = pb.Row_builder{Cells: cells}.Build()
+	}
+	return pb.Stack_builder{Rows: r}.Build()
+}
+
+func piece(t *tetris.Tetromino) *pb.Piece {
+	if t == nil {
+		return nil
+	}
+	grid := make([]*pb.Row, len(t.Grid))
+	for i, y := range t.Grid {
+		cells := make([]string, len(y))
+		for j, x := range y {
+			if x {
+				cells[j] = string(t.Shape)
+			}
+		}
+		grid[i] = pb.Row_builder{Cells: cells}.Build()
+	}
+	return pb.Piece_builder{
+		Shape:  proto.String(string(t.Shape)),
+		X:      proto.Int32(int32(t.X)),      // nolint:gosec
+		Y:      proto.Int32(int32(t.Y)),      // nolint:gosec
+		GhostY: proto.Int32(int32(t.GhostY)), // nolint:gosec
+		Grid:   grid,
+	}.Build()
+}
+
+func tetromino(p *pb.Piece) *tetris.Tetromino {
+	if p == nil {
+		return nil
+	}
+	t := &tetris.Tetromino{
+		Grid:   make([][]bool, len(p.GetGrid())),
+		X:      int(p.GetX()),
+		Y:      int(p.GetY()),
+		GhostY: int(p.GetGhostY()),
+		Shape:  tetris.Shape(p.GetShape()),
+	}
+	for i, r := range p.GetGrid() {
+		t.Grid[i] = make([]bool, len(r.GetCells()))
+		for j, c := range r.GetCells() {
+			t.Grid[i][j] = c != ""
+		}
+	}
+	return t
+}
+
+func lineClear(c tetris.Clear) *pb.Clear {
+	return pb.Clear_builder{
+		Lines: proto.Int32(int32(c.Lines)), // nolint:gosec
+		TSpin: proto.String(string(c.TSpin)),
+	}.Build()
+}
+
+func fromClear(c *pb.Clear) tetris.Clear {
+	return tetris.Clear{Lines: int(c.GetLines()), TSpin: tetris.TSpin(c.GetTSpin())}
+}
+
+// Stack returns the stack of a game with the falling tetromino in it.
+func Stack(t *tetris.Tetris) *pb.Stack {
+	rendered := pb.Stack_builder{Rows: make([]*pb.Row, 20)}.Build()
+
+	for i := range rendered.GetRows() {
+		rendered.GetRows()[i] = pb.Row_builder{
+			Cells: make([]string, 10),
+		}.Build()
+	}
+
+	for iy, y := range t.Stack {
+		for ix, x := range y {
+			if x != tetris.Shape("") {
+				rendered.GetRows()[iy].GetCells()[ix] = string(x)
+			}
+		}
+	}
+
+	// renders the current tetromino if exist
+	if t.Tetromino != nil {
+		for iy, y := range t.Tetromino.Grid {
+			for ix, x := range y {
+				if x {
+					rendered.GetRows()[t.Tetromino.Y-iy].GetCells()[t.Tetromino.X+ix] = string(t.Tetromino.Shape)
+				}
+			}
+		}
+	}
+	return rendered
+}
@@ -0,0 +1,53 @@
+package message
+
+import (
+	"reflect"
+	"testing"
+	"tetris/pb"
+	"tetris/tetris"
+	"time"
+)
+
+func TestStack(t *testing.T) {
+	got := Stack(tetris.NewTestTetris(tetris.J))
+	want := pb.Stack_builder{Rows: make([]*pb.Row, 20)}.Build()
+
+	for i := range want.GetRows() {
+		want.GetRows()[i] = pb.Row_builder{
+			Cells: make([]string, 10),
+		}.Build()
+	}
+	want.GetRows()[19].GetCells()[3] = "J"
+	want.GetRows()[18].GetCells()[3] = "J"
+	want.GetRows()[18].GetCells()[4] = "J"
+	want.GetRows()[18].GetCells()[5] = "J"
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestState(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.T)
+	te.Stack[0][0] = tetris.Garbage
+	te.Seed = 42
+	te.Time = 1500 * time.Millisecond
+	te.LinesCleared = &tetris.LinesCleared{Rows: []int{0, 1}, Clear: tetris.Clear{Lines: 2, TSpin: tetris.TSpinFull}}
+	got := Game(State(te))
+
+	if !reflect.DeepEqual(got.Stack, te.Stack) || !reflect.DeepEqual(got.LinesCleared, te.LinesCleared) {
+		t.Errorf("wanted the stack and the lines cleared of the game, got %v and %v", got.Stack, got.LinesCleared)
+	}
+	if tm := got.Tetromino; tm.Shape != tetris.T || tm.X != te.Tetromino.X || tm.Y != te.Tetromino.Y || tm.GhostY != te.Tetromino.GhostY || !reflect.DeepEqual(tm.Grid, te.Tetromino.Grid) {
+		t.Errorf("wanted the tetromino of the game, got %+v", tm)
+	}
+	if len(got.Next) != 1 || got.Next[0].Shape != tetris.T || got.HoldTetromino != nil {
+		t.Errorf("wanted the next queue and no hold, got %v and %v", got.Next, got.HoldTetromino)
+	}
+	if got.Level != 1 || got.Mode != tetris.Marathon || got.Time != te.Time {
+		t.Errorf("wanted level 1 of marathon at %v, got level %d of %s at %v", te.Time, got.Level, got.Mode, got.Time)
+	}
+	if got.Seed != 0 {
+		t.Errorf("wanted the seed to be left out, got %d", got.Seed)
+	}
+}
@@ -21,34 +21,1661 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Target int32
+
+const (
+	// random targets any opponent still playing.
+	Target_TARGET_RANDOM Target = 0
+	// attackers targets the opponents targeting the player, or any
+	// opponent if there are none.
+	Target_TARGET_ATTACKERS Target = 1
+	// ko targets the opponent with the highest stack, the closest to
+	// being knocked out.
+	Target_TARGET_KO Target = 2
+)
+
+// Enum value maps for Target.
+var (
+	Target_name = map[int32]string{
+		0: "TARGET_RANDOM",
+		1: "TARGET_ATTACKERS",
+		2: "TARGET_KO",
+	}
+	Target_value = map[string]int32{
+		"TARGET_RANDOM":    0,
+		"TARGET_ATTACKERS": 1,
+		"TARGET_KO":        2,
+	}
+)
+
+func (x Target) Enum() *Target {
+	p := new(Target)
+	*p = x
+	return p
+}
+
+func (x Target) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Target) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_server_proto_enumTypes[0].Descriptor()
+}
+
+func (Target) Type() protoreflect.EnumType {
+	return &file_pb_server_proto_enumTypes[0]
+}
+
+func (x Target) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
 type GameMessage struct {
+	state                      protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name            *string                `protobuf:"bytes,1,opt,name=name"`
+	xxx_hidden_IsStarted       bool                   `protobuf:"varint,2,opt,name=is_started,json=isStarted"`
+	xxx_hidden_IsGameOver      bool                   `protobuf:"varint,3,opt,name=is_game_over,json=isGameOver"`
+	xxx_hidden_LinesClear      int32                  `protobuf:"varint,4,opt,name=lines_clear,json=linesClear"`
+	xxx_hidden_Stack           *Stack                 `protobuf:"bytes,5,opt,name=stack"`
+	xxx_hidden_Score           int32                  `protobuf:"varint,6,opt,name=score"`
+	xxx_hidden_GarbageSent     int32                  `protobuf:"varint,7,opt,name=garbage_sent,json=garbageSent"`
+	xxx_hidden_GarbagePending  int32                  `protobuf:"varint,8,opt,name=garbage_pending,json=garbagePending"`
+	xxx_hidden_Room            *string                `protobuf:"bytes,9,opt,name=room"`
+	xxx_hidden_NewRoom         bool                   `protobuf:"varint,10,opt,name=new_room,json=newRoom"`
+	xxx_hidden_Players         int32                  `protobuf:"varint,11,opt,name=players"`
+	xxx_hidden_Player          int32                  `protobuf:"varint,12,opt,name=player"`
+	xxx_hidden_GarbageReceived int32                  `protobuf:"varint,13,opt,name=garbage_received,json=garbageReceived"`
+	xxx_hidden_Target          Target                 `protobuf:"varint,14,opt,name=target,enum=tetris.Target"`
+	xxx_hidden_Left            bool                   `protobuf:"varint,15,opt,name=left"`
+	xxx_hidden_Authoritative   bool                   `protobuf:"varint,16,opt,name=authoritative"`
+	xxx_hidden_Action          *string                `protobuf:"bytes,17,opt,name=action"`
+	xxx_hidden_State           *State                 `protobuf:"bytes,18,opt,name=state"`
+	XXX_raceDetectHookData     protoimpl.RaceDetectHookData
+	XXX_presence               [1]uint32
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *GameMessage) Reset() {
+	*x = GameMessage{}
+	mi := &file_pb_server_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameMessage) ProtoMessage() {}
+
+func (x *GameMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GameMessage) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GameMessage) GetIsStarted() bool {
+	if x != nil {
+		return x.xxx_hidden_IsStarted
+	}
+	return false
+}
+
+func (x *GameMessage) GetIsGameOver() bool {
+	if x != nil {
+		return x.xxx_hidden_IsGameOver
+	}
+	return false
+}
+
+func (x *GameMessage) GetLinesClear() int32 {
+	if x != nil {
+		return x.xxx_hidden_LinesClear
+	}
+	return 0
+}
+
+func (x *GameMessage) GetStack() *Stack {
+	if x != nil {
+		return x.xxx_hidden_Stack
+	}
+	return nil
+}
+
+func (x *GameMessage) GetScore() int32 {
+	if x != nil {
+		return x.xxx_hidden_Score
+	}
+	return 0
+}
+
+func (x *GameMessage) GetGarbageSent() int32 {
+	if x != nil {
+		return x.xxx_hidden_GarbageSent
+	}
+	return 0
+}
+
+func (x *GameMessage) GetGarbagePending() int32 {
+	if x != nil {
+		return x.xxx_hidden_GarbagePending
+	}
+	return 0
+}
+
+func (x *GameMessage) GetRoom() string {
+	if x != nil {
+		if x.xxx_hidden_Room != nil {
+			return *x.xxx_hidden_Room
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GameMessage) GetNewRoom() bool {
+	if x != nil {
+		return x.xxx_hidden_NewRoom
+	}
+	return false
+}
+
+func (x *GameMessage) GetPlayers() int32 {
+	if x != nil {
+		return x.xxx_hidden_Players
+	}
+	return 0
+}
+
+func (x *GameMessage) GetPlayer() int32 {
+	if x != nil {
+		return x.xxx_hidden_Player
+	}
+	return 0
+}
+
+func (x *GameMessage) GetGarbageReceived() int32 {
+	if x != nil {
+		return x.xxx_hidden_GarbageReceived
+	}
+	return 0
+}
+
+func (x *GameMessage) GetTarget() Target {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 13) {
+			return x.xxx_hidden_Target
+		}
+	}
+	return Target_TARGET_RANDOM
+}
+
+func (x *GameMessage) GetLeft() bool {
+	if x != nil {
+		return x.xxx_hidden_Left
+	}
+	return false
+}
+
+func (x *GameMessage) GetAuthoritative() bool {
+	if x != nil {
+		return x.xxx_hidden_Authoritative
+	}
+	return false
+}
+
+func (x *GameMessage) GetAction() string {
+	if x != nil {
+		if x.xxx_hidden_Action != nil {
+			return *x.xxx_hidden_Action
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GameMessage) GetState() *State {
+	if x != nil {
+		return x.xxx_hidden_State
+	}
+	return nil
+}
+
+func (x *GameMessage) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 18)
+}
+
+func (x *GameMessage) SetIsStarted(v bool) {
+	x.xxx_hidden_IsStarted = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 18)
+}
+
+func (x *GameMessage) SetIsGameOver(v bool) {
+	x.xxx_hidden_IsGameOver = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 18)
+}
+
+func (x *GameMessage) SetLinesClear(v int32) {
+	x.xxx_hidden_LinesClear = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 18)
+}
+
+func (x *GameMessage) SetStack(v *Stack) {
+	x.xxx_hidden_Stack = v
+}
+
+func (x *GameMessage) SetScore(v int32) {
+	x.xxx_hidden_Score = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 18)
+}
+
+func (x *GameMessage) SetGarbageSent(v int32) {
+	x.xxx_hidden_GarbageSent = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 18)
+}
+
+func (x *GameMessage) SetGarbagePending(v int32) {
+	x.xxx_hidden_GarbagePending = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 18)
+}
+
+func (x *GameMessage) SetRoom(v string) {
+	x.xxx_hidden_Room = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 8, 18)
+}
+
+func (x *GameMessage) SetNewRoom(v bool) {
+	x.xxx_hidden_NewRoom = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 9, 18)
+}
+
+func (x *GameMessage) SetPlayers(v int32) {
+	x.xxx_hidden_Players = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 10, 18)
+}
+
+func (x *GameMessage) SetPlayer(v int32) {
+	x.xxx_hidden_Player = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 11, 18)
+}
+
+func (x *GameMessage) SetGarbageReceived(v int32) {
+	x.xxx_hidden_GarbageReceived = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 12, 18)
+}
+
+func (x *GameMessage) SetTarget(v Target) {
+	x.xxx_hidden_Target = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 13, 18)
+}
+
+func (x *GameMessage) SetLeft(v bool) {
+	x.xxx_hidden_Left = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 14, 18)
+}
+
+func (x *GameMessage) SetAuthoritative(v bool) {
+	x.xxx_hidden_Authoritative = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 15, 18)
+}
+
+func (x *GameMessage) SetAction(v string) {
+	x.xxx_hidden_Action = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 16, 18)
+}
+
+func (x *GameMessage) SetState(v *State) {
+	x.xxx_hidden_State = v
+}
+
+func (x *GameMessage) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *GameMessage) HasIsStarted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *GameMessage) HasIsGameOver() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *GameMessage) HasLinesClear() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *GameMessage) HasStack() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Stack != nil
+}
+
+func (x *GameMessage) HasScore() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *GameMessage) HasGarbageSent() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *GameMessage) HasGarbagePending() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
+func (x *GameMessage) HasRoom() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 8)
+}
+
+func (x *GameMessage) HasNewRoom() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 9)
+}
+
+func (x *GameMessage) HasPlayers() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 10)
+}
+
+func (x *GameMessage) HasPlayer() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 11)
+}
+
+func (x *GameMessage) HasGarbageReceived() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 12)
+}
+
+func (x *GameMessage) HasTarget() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 13)
+}
+
+func (x *GameMessage) HasLeft() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 14)
+}
+
+func (x *GameMessage) HasAuthoritative() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 15)
+}
+
+func (x *GameMessage) HasAction() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 16)
+}
+
+func (x *GameMessage) HasState() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_State != nil
+}
+
+func (x *GameMessage) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *GameMessage) ClearIsStarted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_IsStarted = false
+}
+
+func (x *GameMessage) ClearIsGameOver() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_IsGameOver = false
+}
+
+func (x *GameMessage) ClearLinesClear() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_LinesClear = 0
+}
+
+func (x *GameMessage) ClearStack() {
+	x.xxx_hidden_Stack = nil
+}
+
+func (x *GameMessage) ClearScore() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Score = 0
+}
+
+func (x *GameMessage) ClearGarbageSent() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_GarbageSent = 0
+}
+
+func (x *GameMessage) ClearGarbagePending() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_GarbagePending = 0
+}
+
+func (x *GameMessage) ClearRoom() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 8)
+	x.xxx_hidden_Room = nil
+}
+
+func (x *GameMessage) ClearNewRoom() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 9)
+	x.xxx_hidden_NewRoom = false
+}
+
+func (x *GameMessage) ClearPlayers() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 10)
+	x.xxx_hidden_Players = 0
+}
+
+func (x *GameMessage) ClearPlayer() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 11)
+	x.xxx_hidden_Player = 0
+}
+
+func (x *GameMessage) ClearGarbageReceived() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 12)
+	x.xxx_hidden_GarbageReceived = 0
+}
+
+func (x *GameMessage) ClearTarget() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 13)
+	x.xxx_hidden_Target = Target_TARGET_RANDOM
+}
+
+func (x *GameMessage) ClearLeft() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 14)
+	x.xxx_hidden_Left = false
+}
+
+func (x *GameMessage) ClearAuthoritative() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 15)
+	x.xxx_hidden_Authoritative = false
+}
+
+func (x *GameMessage) ClearAction() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 16)
+	x.xxx_hidden_Action = nil
+}
+
+func (x *GameMessage) ClearState() {
+	x.xxx_hidden_State = nil
+}
+
+type GameMessage_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Name       *string
+	IsStarted  *bool
+	IsGameOver *bool
+	LinesClear *int32
+	Stack      *Stack
+	Score      *int32
+	// garbage_sent are the garbage lines sent to the opponent so far.
+	GarbageSent *int32
+	// garbage_pending are the garbage lines waiting to rise into the stack.
+	GarbagePending *int32
+	// room is the code of the room to play in, any opponent is matched
+	// without one. rooms are created with new_room, and joining one with
+	// an unknown code fails with NOT_FOUND.
+	Room *string
+	// new_room asks the server to create a room with a new code, which
+	// is sent back in room.
+	NewRoom *bool
+	// players is the number of players of the match, from 2 (the default)
+	// to 8. it's set by the player who creates the match and sent back
+	// when it starts.
+	Players *int32
+	// player is the seat in the match of the player the message is about,
+	// set by the server.
+	Player *int32
+	// garbage_received is set by the server to all the garbage lines the
+	// receiving player has been sent so far by its opponents.
+	GarbageReceived *int32
+	// target is how the garbage of the player picks its opponent.
+	Target *Target
+	// left is set by the server when the player left the match before
+	// it was over.
+	Left *bool
+	// authoritative is set in the first message of a match when the
+	// server runs the games of the players. players then only send their
+	// actions, and get the state of their own game back.
+	Authoritative *bool
+	// action is the tetris action of the player in authoritative matches.
+	Action *string
+	// state is the game of the receiving player in authoritative matches.
+	State *State
+}
+
+func (b0 GameMessage_builder) Build() *GameMessage {
+	m0 := &GameMessage{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 18)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.IsStarted != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 18)
+		x.xxx_hidden_IsStarted = *b.IsStarted
+	}
+	if b.IsGameOver != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 18)
+		x.xxx_hidden_IsGameOver = *b.IsGameOver
+	}
+	if b.LinesClear != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 18)
+		x.xxx_hidden_LinesClear = *b.LinesClear
+	}
+	x.xxx_hidden_Stack = b.Stack
+	if b.Score != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 18)
+		x.xxx_hidden_Score = *b.Score
+	}
+	if b.GarbageSent != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 18)
+		x.xxx_hidden_GarbageSent = *b.GarbageSent
+	}
+	if b.GarbagePending != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 18)
+		x.xxx_hidden_GarbagePending = *b.GarbagePending
+	}
+	if b.Room != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 8, 18)
+		x.xxx_hidden_Room = b.Room
+	}
+	if b.NewRoom != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 9, 18)
+		x.xxx_hidden_NewRoom = *b.NewRoom
+	}
+	if b.Players != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 10, 18)
+		x.xxx_hidden_Players = *b.Players
+	}
+	if b.Player != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 11, 18)
+		x.xxx_hidden_Player = *b.Player
+	}
+	if b.GarbageReceived != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 12, 18)
+		x.xxx_hidden_GarbageReceived = *b.GarbageReceived
+	}
+	if b.Target != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 13, 18)
+		x.xxx_hidden_Target = *b.Target
+	}
+	if b.Left != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 14, 18)
+		x.xxx_hidden_Left = *b.Left
+	}
+	if b.Authoritative != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 15, 18)
+		x.xxx_hidden_Authoritative = *b.Authoritative
+	}
+	if b.Action != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 16, 18)
+		x.xxx_hidden_Action = b.Action
+	}
+	x.xxx_hidden_State = b.State
+	return m0
+}
+
+type ListGamesRequest struct {
+	state         protoimpl.MessageState `protogen:"opaque.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGamesRequest) Reset() {
+	*x = ListGamesRequest{}
+	mi := &file_pb_server_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGamesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGamesRequest) ProtoMessage() {}
+
+func (x *ListGamesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+type ListGamesRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+}
+
+func (b0 ListGamesRequest_builder) Build() *ListGamesRequest {
+	m0 := &ListGamesRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	return m0
+}
+
+type ListGamesResponse struct {
+	state            protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Games *[]*GameInfo           `protobuf:"bytes,1,rep,name=games"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListGamesResponse) Reset() {
+	*x = ListGamesResponse{}
+	mi := &file_pb_server_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGamesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGamesResponse) ProtoMessage() {}
+
+func (x *ListGamesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ListGamesResponse) GetGames() []*GameInfo {
+	if x != nil {
+		if x.xxx_hidden_Games != nil {
+			return *x.xxx_hidden_Games
+		}
+	}
+	return nil
+}
+
+func (x *ListGamesResponse) SetGames(v []*GameInfo) {
+	x.xxx_hidden_Games = &v
+}
+
+type ListGamesResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Games []*GameInfo
+}
+
+func (b0 ListGamesResponse_builder) Build() *ListGamesResponse {
+	m0 := &ListGamesResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Games = &b.Games
+	return m0
+}
+
+type GameInfo struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Id          int32                  `protobuf:"varint,1,opt,name=id"`
+	xxx_hidden_Players     []string               `protobuf:"bytes,2,rep,name=players"`
+	xxx_hidden_Playing     int32                  `protobuf:"varint,3,opt,name=playing"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GameInfo) Reset() {
+	*x = GameInfo{}
+	mi := &file_pb_server_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameInfo) ProtoMessage() {}
+
+func (x *GameInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GameInfo) GetId() int32 {
+	if x != nil {
+		return x.xxx_hidden_Id
+	}
+	return 0
+}
+
+func (x *GameInfo) GetPlayers() []string {
+	if x != nil {
+		return x.xxx_hidden_Players
+	}
+	return nil
+}
+
+func (x *GameInfo) GetPlaying() int32 {
+	if x != nil {
+		return x.xxx_hidden_Playing
+	}
+	return 0
+}
+
+func (x *GameInfo) SetId(v int32) {
+	x.xxx_hidden_Id = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *GameInfo) SetPlayers(v []string) {
+	x.xxx_hidden_Players = v
+}
+
+func (x *GameInfo) SetPlaying(v int32) {
+	x.xxx_hidden_Playing = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
+}
+
+func (x *GameInfo) HasId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *GameInfo) HasPlaying() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *GameInfo) ClearId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Id = 0
+}
+
+func (x *GameInfo) ClearPlaying() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Playing = 0
+}
+
+type GameInfo_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Id *int32
+	// players are the names of the players by seat.
+	Players []string
+	// playing is how many players haven't been knocked out yet.
+	Playing *int32
+}
+
+func (b0 GameInfo_builder) Build() *GameInfo {
+	m0 := &GameInfo{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Id != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Id = *b.Id
+	}
+	x.xxx_hidden_Players = b.Players
+	if b.Playing != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
+		x.xxx_hidden_Playing = *b.Playing
+	}
+	return m0
+}
+
+type SpectateRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Game        int32                  `protobuf:"varint,1,opt,name=game"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *SpectateRequest) Reset() {
+	*x = SpectateRequest{}
+	mi := &file_pb_server_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpectateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpectateRequest) ProtoMessage() {}
+
+func (x *SpectateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *SpectateRequest) GetGame() int32 {
+	if x != nil {
+		return x.xxx_hidden_Game
+	}
+	return 0
+}
+
+func (x *SpectateRequest) SetGame(v int32) {
+	x.xxx_hidden_Game = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+}
+
+func (x *SpectateRequest) HasGame() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *SpectateRequest) ClearGame() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Game = 0
+}
+
+type SpectateRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// game is the id of the match to watch.
+	Game *int32
+}
+
+func (b0 SpectateRequest_builder) Build() *SpectateRequest {
+	m0 := &SpectateRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Game != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		x.xxx_hidden_Game = *b.Game
+	}
+	return m0
+}
+
+type Stack struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Rows *[]*Row                `protobuf:"bytes,1,rep,name=rows"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Stack) Reset() {
+	*x = Stack{}
+	mi := &file_pb_server_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stack) ProtoMessage() {}
+
+func (x *Stack) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Stack) GetRows() []*Row {
+	if x != nil {
+		if x.xxx_hidden_Rows != nil {
+			return *x.xxx_hidden_Rows
+		}
+	}
+	return nil
+}
+
+func (x *Stack) SetRows(v []*Row) {
+	x.xxx_hidden_Rows = &v
+}
+
+type Stack_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Rows []*Row
+}
+
+func (b0 Stack_builder) Build() *Stack {
+	m0 := &Stack{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Rows = &b.Rows
+	return m0
+}
+
+type Row struct {
+	state            protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Cells []string               `protobuf:"bytes,1,rep,name=cells"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Row) Reset() {
+	*x = Row{}
+	mi := &file_pb_server_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Row) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Row) ProtoMessage() {}
+
+func (x *Row) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Row) GetCells() []string {
+	if x != nil {
+		return x.xxx_hidden_Cells
+	}
+	return nil
+}
+
+func (x *Row) SetCells(v []string) {
+	x.xxx_hidden_Cells = v
+}
+
+type Row_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Cells []string
+}
+
+func (b0 Row_builder) Build() *Row {
+	m0 := &Row{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Cells = b.Cells
+	return m0
+}
+
+// State is the game of a player as the server runs it.
+type State struct {
+	state                     protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Stack          *Stack                 `protobuf:"bytes,1,opt,name=stack"`
+	xxx_hidden_Tetromino      *Piece                 `protobuf:"bytes,2,opt,name=tetromino"`
+	xxx_hidden_Next           *[]*Piece              `protobuf:"bytes,3,rep,name=next"`
+	xxx_hidden_Hold           *Piece                 `protobuf:"bytes,4,opt,name=hold"`
+	xxx_hidden_Level          int32                  `protobuf:"varint,5,opt,name=level"`
+	xxx_hidden_LinesClear     int32                  `protobuf:"varint,6,opt,name=lines_clear,json=linesClear"`
+	xxx_hidden_Score          int32                  `protobuf:"varint,7,opt,name=score"`
+	xxx_hidden_Pieces         int32                  `protobuf:"varint,8,opt,name=pieces"`
+	xxx_hidden_LastClear      *Clear                 `protobuf:"bytes,9,opt,name=last_clear,json=lastClear"`
+	xxx_hidden_LinesCleared   *LinesCleared          `protobuf:"bytes,10,opt,name=lines_cleared,json=linesCleared"`
+	xxx_hidden_BackToBack     int32                  `protobuf:"varint,11,opt,name=back_to_back,json=backToBack"`
+	xxx_hidden_Combo          int32                  `protobuf:"varint,12,opt,name=combo"`
+	xxx_hidden_GarbageSent    int32                  `protobuf:"varint,13,opt,name=garbage_sent,json=garbageSent"`
+	xxx_hidden_GarbagePending int32                  `protobuf:"varint,14,opt,name=garbage_pending,json=garbagePending"`
+	xxx_hidden_Mode           *string                `protobuf:"bytes,15,opt,name=mode"`
+	xxx_hidden_Time           int64                  `protobuf:"varint,16,opt,name=time"`
+	xxx_hidden_Won            bool                   `protobuf:"varint,17,opt,name=won"`
+	xxx_hidden_IsGameOver     bool                   `protobuf:"varint,18,opt,name=is_game_over,json=isGameOver"`
+	XXX_raceDetectHookData    protoimpl.RaceDetectHookData
+	XXX_presence              [1]uint32
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_pb_server_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *State) GetStack() *Stack {
+	if x != nil {
+		return x.xxx_hidden_Stack
+	}
+	return nil
+}
+
+func (x *State) GetTetromino() *Piece {
+	if x != nil {
+		return x.xxx_hidden_Tetromino
+	}
+	return nil
+}
+
+func (x *State) GetNext() []*Piece {
+	if x != nil {
+		if x.xxx_hidden_Next != nil {
+			return *x.xxx_hidden_Next
+		}
+	}
+	return nil
+}
+
+func (x *State) GetHold() *Piece {
+	if x != nil {
+		return x.xxx_hidden_Hold
+	}
+	return nil
+}
+
+func (x *State) GetLevel() int32 {
+	if x != nil {
+		return x.xxx_hidden_Level
+	}
+	return 0
+}
+
+func (x *State) GetLinesClear() int32 {
+	if x != nil {
+		return x.xxx_hidden_LinesClear
+	}
+	return 0
+}
+
+func (x *State) GetScore() int32 {
+	if x != nil {
+		return x.xxx_hidden_Score
+	}
+	return 0
+}
+
+func (x *State) GetPieces() int32 {
+	if x != nil {
+		return x.xxx_hidden_Pieces
+	}
+	return 0
+}
+
+func (x *State) GetLastClear() *Clear {
+	if x != nil {
+		return x.xxx_hidden_LastClear
+	}
+	return nil
+}
+
+func (x *State) GetLinesCleared() *LinesCleared {
+	if x != nil {
+		return x.xxx_hidden_LinesCleared
+	}
+	return nil
+}
+
+func (x *State) GetBackToBack() int32 {
+	if x != nil {
+		return x.xxx_hidden_BackToBack
+	}
+	return 0
+}
+
+func (x *State) GetCombo() int32 {
+	if x != nil {
+		return x.xxx_hidden_Combo
+	}
+	return 0
+}
+
+func (x *State) GetGarbageSent() int32 {
+	if x != nil {
+		return x.xxx_hidden_GarbageSent
+	}
+	return 0
+}
+
+func (x *State) GetGarbagePending() int32 {
+	if x != nil {
+		return x.xxx_hidden_GarbagePending
+	}
+	return 0
+}
+
+func (x *State) GetMode() string {
+	if x != nil {
+		if x.xxx_hidden_Mode != nil {
+			return *x.xxx_hidden_Mode
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *State) GetTime() int64 {
+	if x != nil {
+		return x.xxx_hidden_Time
+	}
+	return 0
+}
+
+func (x *State) GetWon() bool {
+	if x != nil {
+		return x.xxx_hidden_Won
+	}
+	return false
+}
+
+func (x *State) GetIsGameOver() bool {
+	if x != nil {
+		return x.xxx_hidden_IsGameOver
+	}
+	return false
+}
+
+func (x *State) SetStack(v *Stack) {
+	x.xxx_hidden_Stack = v
+}
+
+func (x *State) SetTetromino(v *Piece) {
+	x.xxx_hidden_Tetromino = v
+}
+
+func (x *State) SetNext(v []*Piece) {
+	x.xxx_hidden_Next = &v
+}
+
+func (x *State) SetHold(v *Piece) {
+	x.xxx_hidden_Hold = v
+}
+
+func (x *State) SetLevel(v int32) {
+	x.xxx_hidden_Level = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 18)
+}
+
+func (x *State) SetLinesClear(v int32) {
+	x.xxx_hidden_LinesClear = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 18)
+}
+
+func (x *State) SetScore(v int32) {
+	x.xxx_hidden_Score = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 18)
+}
+
+func (x *State) SetPieces(v int32) {
+	x.xxx_hidden_Pieces = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 18)
+}
+
+func (x *State) SetLastClear(v *Clear) {
+	x.xxx_hidden_LastClear = v
+}
+
+func (x *State) SetLinesCleared(v *LinesCleared) {
+	x.xxx_hidden_LinesCleared = v
+}
+
+func (x *State) SetBackToBack(v int32) {
+	x.xxx_hidden_BackToBack = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 10, 18)
+}
+
+func (x *State) SetCombo(v int32) {
+	x.xxx_hidden_Combo = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 11, 18)
+}
+
+func (x *State) SetGarbageSent(v int32) {
+	x.xxx_hidden_GarbageSent = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 12, 18)
+}
+
+func (x *State) SetGarbagePending(v int32) {
+	x.xxx_hidden_GarbagePending = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 13, 18)
+}
+
+func (x *State) SetMode(v string) {
+	x.xxx_hidden_Mode = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 14, 18)
+}
+
+func (x *State) SetTime(v int64) {
+	x.xxx_hidden_Time = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 15, 18)
+}
+
+func (x *State) SetWon(v bool) {
+	x.xxx_hidden_Won = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 16, 18)
+}
+
+func (x *State) SetIsGameOver(v bool) {
+	x.xxx_hidden_IsGameOver = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 17, 18)
+}
+
+func (x *State) HasStack() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Stack != nil
+}
+
+func (x *State) HasTetromino() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Tetromino != nil
+}
+
+func (x *State) HasHold() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Hold != nil
+}
+
+func (x *State) HasLevel() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *State) HasLinesClear() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *State) HasScore() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *State) HasPieces() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
+func (x *State) HasLastClear() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_LastClear != nil
+}
+
+func (x *State) HasLinesCleared() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_LinesCleared != nil
+}
+
+func (x *State) HasBackToBack() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 10)
+}
+
+func (x *State) HasCombo() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 11)
+}
+
+func (x *State) HasGarbageSent() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 12)
+}
+
+func (x *State) HasGarbagePending() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 13)
+}
+
+func (x *State) HasMode() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 14)
+}
+
+func (x *State) HasTime() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 15)
+}
+
+func (x *State) HasWon() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 16)
+}
+
+func (x *State) HasIsGameOver() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 17)
+}
+
+func (x *State) ClearStack() {
+	x.xxx_hidden_Stack = nil
+}
+
+func (x *State) ClearTetromino() {
+	x.xxx_hidden_Tetromino = nil
+}
+
+func (x *State) ClearHold() {
+	x.xxx_hidden_Hold = nil
+}
+
+func (x *State) ClearLevel() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_Level = 0
+}
+
+func (x *State) ClearLinesClear() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_LinesClear = 0
+}
+
+func (x *State) ClearScore() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_Score = 0
+}
+
+func (x *State) ClearPieces() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_Pieces = 0
+}
+
+func (x *State) ClearLastClear() {
+	x.xxx_hidden_LastClear = nil
+}
+
+func (x *State) ClearLinesCleared() {
+	x.xxx_hidden_LinesCleared = nil
+}
+
+func (x *State) ClearBackToBack() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 10)
+	x.xxx_hidden_BackToBack = 0
+}
+
+func (x *State) ClearCombo() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 11)
+	x.xxx_hidden_Combo = 0
+}
+
+func (x *State) ClearGarbageSent() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 12)
+	x.xxx_hidden_GarbageSent = 0
+}
+
+func (x *State) ClearGarbagePending() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 13)
+	x.xxx_hidden_GarbagePending = 0
+}
+
+func (x *State) ClearMode() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 14)
+	x.xxx_hidden_Mode = nil
+}
+
+func (x *State) ClearTime() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 15)
+	x.xxx_hidden_Time = 0
+}
+
+func (x *State) ClearWon() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 16)
+	x.xxx_hidden_Won = false
+}
+
+func (x *State) ClearIsGameOver() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 17)
+	x.xxx_hidden_IsGameOver = false
+}
+
+type State_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// stack is the playfield without the falling tetromino.
+	Stack      *Stack
+	Tetromino  *Piece
+	Next       []*Piece
+	Hold       *Piece
+	Level      *int32
+	LinesClear *int32
+	Score      *int32
+	Pieces     *int32
+	LastClear  *Clear
+	// lines_cleared are the lines completed by the last lock while they
+	// wait for the line clear delay to be removed.
+	LinesCleared   *LinesCleared
+	BackToBack     *int32
+	Combo          *int32
+	GarbageSent    *int32
+	GarbagePending *int32
+	Mode           *string
+	// time is the time elapsed since the game started in milliseconds.
+	Time       *int64
+	Won        *bool
+	IsGameOver *bool
+}
+
+func (b0 State_builder) Build() *State {
+	m0 := &State{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Stack = b.Stack
+	x.xxx_hidden_Tetromino = b.Tetromino
+	x.xxx_hidden_Next = &b.Next
+	x.xxx_hidden_Hold = b.Hold
+	if b.Level != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 18)
+		x.xxx_hidden_Level = *b.Level
+	}
+	if b.LinesClear != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 18)
+		x.xxx_hidden_LinesClear = *b.LinesClear
+	}
+	if b.Score != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 18)
+		x.xxx_hidden_Score = *b.Score
+	}
+	if b.Pieces != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 18)
+		x.xxx_hidden_Pieces = *b.Pieces
+	}
+	x.xxx_hidden_LastClear = b.LastClear
+	x.xxx_hidden_LinesCleared = b.LinesCleared
+	if b.BackToBack != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 10, 18)
+		x.xxx_hidden_BackToBack = *b.BackToBack
+	}
+	if b.Combo != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 11, 18)
+		x.xxx_hidden_Combo = *b.Combo
+	}
+	if b.GarbageSent != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 12, 18)
+		x.xxx_hidden_GarbageSent = *b.GarbageSent
+	}
+	if b.GarbagePending != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 13, 18)
+		x.xxx_hidden_GarbagePending = *b.GarbagePending
+	}
+	if b.Mode != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 14, 18)
+		x.xxx_hidden_Mode = b.Mode
+	}
+	if b.Time != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 15, 18)
+		x.xxx_hidden_Time = *b.Time
+	}
+	if b.Won != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 16, 18)
+		x.xxx_hidden_Won = *b.Won
+	}
+	if b.IsGameOver != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 17, 18)
+		x.xxx_hidden_IsGameOver = *b.IsGameOver
+	}
+	return m0
+}
+
+// Piece is a tetromino in its current rotation.
+type Piece struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Name        *string                `protobuf:"bytes,1,opt,name=name"`
-	xxx_hidden_IsStarted   bool                   `protobuf:"varint,2,opt,name=is_started,json=isStarted"`
-	xxx_hidden_IsGameOver  bool                   `protobuf:"varint,3,opt,name=is_game_over,json=isGameOver"`
-	xxx_hidden_LinesClear  int32                  `protobuf:"varint,4,opt,name=lines_clear,json=linesClear"`
-	xxx_hidden_Stack       *Stack                 `protobuf:"bytes,5,opt,name=stack"`
+	xxx_hidden_Shape       *string                `protobuf:"bytes,1,opt,name=shape"`
+	xxx_hidden_X           int32                  `protobuf:"varint,2,opt,name=x"`
+	xxx_hidden_Y           int32                  `protobuf:"varint,3,opt,name=y"`
+	xxx_hidden_GhostY      int32                  `protobuf:"varint,4,opt,name=ghost_y,json=ghostY"`
+	xxx_hidden_Grid        *[]*Row                `protobuf:"bytes,5,rep,name=grid"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *GameMessage) Reset() {
-	*x = GameMessage{}
-	mi := &file_pb_server_proto_msgTypes[0]
+func (x *Piece) Reset() {
+	*x = Piece{}
+	mi := &file_pb_server_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GameMessage) String() string {
+func (x *Piece) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GameMessage) ProtoMessage() {}
+func (*Piece) ProtoMessage() {}
 
-func (x *GameMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_server_proto_msgTypes[0]
+func (x *Piece) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -59,183 +1686,179 @@ func (x *GameMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *GameMessage) GetName() string {
+func (x *Piece) GetShape() string {
 	if x != nil {
-		if x.xxx_hidden_Name != nil {
-			return *x.xxx_hidden_Name
+		if x.xxx_hidden_Shape != nil {
+			return *x.xxx_hidden_Shape
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *GameMessage) GetIsStarted() bool {
+func (x *Piece) GetX() int32 {
 	if x != nil {
-		return x.xxx_hidden_IsStarted
+		return x.xxx_hidden_X
 	}
-	return false
+	return 0
 }
 
-func (x *GameMessage) GetIsGameOver() bool {
+func (x *Piece) GetY() int32 {
 	if x != nil {
-		return x.xxx_hidden_IsGameOver
+		return x.xxx_hidden_Y
 	}
-	return false
+	return 0
 }
 
-func (x *GameMessage) GetLinesClear() int32 {
+func (x *Piece) GetGhostY() int32 {
 	if x != nil {
-		return x.xxx_hidden_LinesClear
+		return x.xxx_hidden_GhostY
 	}
 	return 0
 }
 
-func (x *GameMessage) GetStack() *Stack {
+func (x *Piece) GetGrid() []*Row {
 	if x != nil {
-		return x.xxx_hidden_Stack
+		if x.xxx_hidden_Grid != nil {
+			return *x.xxx_hidden_Grid
+		}
 	}
 	return nil
 }
 
-func (x *GameMessage) SetName(v string) {
-	x.xxx_hidden_Name = &v
+func (x *Piece) SetShape(v string) {
+	x.xxx_hidden_Shape = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
 }
 
-func (x *GameMessage) SetIsStarted(v bool) {
-	x.xxx_hidden_IsStarted = v
+func (x *Piece) SetX(v int32) {
+	x.xxx_hidden_X = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
 }
 
-func (x *GameMessage) SetIsGameOver(v bool) {
-	x.xxx_hidden_IsGameOver = v
+func (x *Piece) SetY(v int32) {
+	x.xxx_hidden_Y = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
 }
 
-func (x *GameMessage) SetLinesClear(v int32) {
-	x.xxx_hidden_LinesClear = v
+func (x *Piece) SetGhostY(v int32) {
+	x.xxx_hidden_GhostY = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
 }
 
-func (x *GameMessage) SetStack(v *Stack) {
-	x.xxx_hidden_Stack = v
+func (x *Piece) SetGrid(v []*Row) {
+	x.xxx_hidden_Grid = &v
 }
 
-func (x *GameMessage) HasName() bool {
+func (x *Piece) HasShape() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *GameMessage) HasIsStarted() bool {
+func (x *Piece) HasX() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *GameMessage) HasIsGameOver() bool {
+func (x *Piece) HasY() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (x *GameMessage) HasLinesClear() bool {
+func (x *Piece) HasGhostY() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
 }
 
-func (x *GameMessage) HasStack() bool {
-	if x == nil {
-		return false
-	}
-	return x.xxx_hidden_Stack != nil
-}
-
-func (x *GameMessage) ClearName() {
+func (x *Piece) ClearShape() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Name = nil
+	x.xxx_hidden_Shape = nil
 }
 
-func (x *GameMessage) ClearIsStarted() {
+func (x *Piece) ClearX() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_IsStarted = false
+	x.xxx_hidden_X = 0
 }
 
-func (x *GameMessage) ClearIsGameOver() {
+func (x *Piece) ClearY() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
-	x.xxx_hidden_IsGameOver = false
+	x.xxx_hidden_Y = 0
 }
 
-func (x *GameMessage) ClearLinesClear() {
+func (x *Piece) ClearGhostY() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
-	x.xxx_hidden_LinesClear = 0
-}
-
-func (x *GameMessage) ClearStack() {
-	x.xxx_hidden_Stack = nil
+	x.xxx_hidden_GhostY = 0
 }
 
-type GameMessage_builder struct {
+type Piece_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Name       *string
-	IsStarted  *bool
-	IsGameOver *bool
-	LinesClear *int32
-	Stack      *Stack
+	Shape  *string
+	X      *int32
+	Y      *int32
+	GhostY *int32
+	// grid are the rows of the tetromino top to bottom, with the shape in
+	// the cells it fills.
+	Grid []*Row
 }
 
-func (b0 GameMessage_builder) Build() *GameMessage {
-	m0 := &GameMessage{}
+func (b0 Piece_builder) Build() *Piece {
+	m0 := &Piece{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Name != nil {
+	if b.Shape != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
-		x.xxx_hidden_Name = b.Name
+		x.xxx_hidden_Shape = b.Shape
 	}
-	if b.IsStarted != nil {
+	if b.X != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
-		x.xxx_hidden_IsStarted = *b.IsStarted
+		x.xxx_hidden_X = *b.X
 	}
-	if b.IsGameOver != nil {
+	if b.Y != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
-		x.xxx_hidden_IsGameOver = *b.IsGameOver
+		x.xxx_hidden_Y = *b.Y
 	}
-	if b.LinesClear != nil {
+	if b.GhostY != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
-		x.xxx_hidden_LinesClear = *b.LinesClear
+		x.xxx_hidden_GhostY = *b.GhostY
 	}
-	x.xxx_hidden_Stack = b.Stack
+	x.xxx_hidden_Grid = &b.Grid
 	return m0
 }
 
-type Stack struct {
-	state           protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Rows *[]*Row                `protobuf:"bytes,1,rep,name=rows"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type Clear struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Lines       int32                  `protobuf:"varint,1,opt,name=lines"`
+	xxx_hidden_TSpin       *string                `protobuf:"bytes,2,opt,name=t_spin,json=tSpin"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *Stack) Reset() {
-	*x = Stack{}
-	mi := &file_pb_server_proto_msgTypes[1]
+func (x *Clear) Reset() {
+	*x = Clear{}
+	mi := &file_pb_server_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Stack) String() string {
+func (x *Clear) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Stack) ProtoMessage() {}
+func (*Clear) ProtoMessage() {}
 
-func (x *Stack) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_server_proto_msgTypes[1]
+func (x *Clear) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -246,55 +1869,102 @@ func (x *Stack) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *Stack) GetRows() []*Row {
+func (x *Clear) GetLines() int32 {
 	if x != nil {
-		if x.xxx_hidden_Rows != nil {
-			return *x.xxx_hidden_Rows
+		return x.xxx_hidden_Lines
+	}
+	return 0
+}
+
+func (x *Clear) GetTSpin() string {
+	if x != nil {
+		if x.xxx_hidden_TSpin != nil {
+			return *x.xxx_hidden_TSpin
 		}
+		return ""
 	}
-	return nil
+	return ""
 }
 
-func (x *Stack) SetRows(v []*Row) {
-	x.xxx_hidden_Rows = &v
+func (x *Clear) SetLines(v int32) {
+	x.xxx_hidden_Lines = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
 }
 
-type Stack_builder struct {
+func (x *Clear) SetTSpin(v string) {
+	x.xxx_hidden_TSpin = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *Clear) HasLines() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *Clear) HasTSpin() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *Clear) ClearLines() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Lines = 0
+}
+
+func (x *Clear) ClearTSpin() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_TSpin = nil
+}
+
+type Clear_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Rows []*Row
+	Lines *int32
+	TSpin *string
 }
 
-func (b0 Stack_builder) Build() *Stack {
-	m0 := &Stack{}
+func (b0 Clear_builder) Build() *Clear {
+	m0 := &Clear{}
 	b, x := &b0, m0
 	_, _ = b, x
-	x.xxx_hidden_Rows = &b.Rows
+	if b.Lines != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Lines = *b.Lines
+	}
+	if b.TSpin != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_TSpin = b.TSpin
+	}
 	return m0
 }
 
-type Row struct {
+type LinesCleared struct {
 	state            protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Cells []string               `protobuf:"bytes,1,rep,name=cells"`
+	xxx_hidden_Rows  []int32                `protobuf:"varint,1,rep,packed,name=rows"`
+	xxx_hidden_Clear *Clear                 `protobuf:"bytes,2,opt,name=clear"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
-func (x *Row) Reset() {
-	*x = Row{}
-	mi := &file_pb_server_proto_msgTypes[2]
+func (x *LinesCleared) Reset() {
+	*x = LinesCleared{}
+	mi := &file_pb_server_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Row) String() string {
+func (x *LinesCleared) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Row) ProtoMessage() {}
+func (*LinesCleared) ProtoMessage() {}
 
-func (x *Row) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_server_proto_msgTypes[2]
+func (x *LinesCleared) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_server_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -305,28 +1975,52 @@ func (x *Row) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *Row) GetCells() []string {
+func (x *LinesCleared) GetRows() []int32 {
 	if x != nil {
-		return x.xxx_hidden_Cells
+		return x.xxx_hidden_Rows
 	}
 	return nil
 }
 
-func (x *Row) SetCells(v []string) {
-	x.xxx_hidden_Cells = v
+func (x *LinesCleared) GetClear() *Clear {
+	if x != nil {
+		return x.xxx_hidden_Clear
+	}
+	return nil
 }
 
-type Row_builder struct {
+func (x *LinesCleared) SetRows(v []int32) {
+	x.xxx_hidden_Rows = v
+}
+
+func (x *LinesCleared) SetClear(v *Clear) {
+	x.xxx_hidden_Clear = v
+}
+
+func (x *LinesCleared) HasClear() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Clear != nil
+}
+
+func (x *LinesCleared) ClearClear() {
+	x.xxx_hidden_Clear = nil
+}
+
+type LinesCleared_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Cells []string
+	Rows  []int32
+	Clear *Clear
 }
 
-func (b0 Row_builder) Build() *Row {
-	m0 := &Row{}
+func (b0 LinesCleared_builder) Build() *LinesCleared {
+	m0 := &LinesCleared{}
 	b, x := &b0, m0
 	_, _ = b, x
-	x.xxx_hidden_Cells = b.Cells
+	x.xxx_hidden_Rows = b.Rows
+	x.xxx_hidden_Clear = b.Clear
 	return m0
 }
 
@@ -334,7 +2028,7 @@ var File_pb_server_proto protoreflect.FileDescriptor
 
 const file_pb_server_proto_rawDesc = "" +
 	"\n" +
-	"\x0fpb/server.proto\x12\x06tetris\x1a!google/protobuf/go_features.proto\"\xa8\x01\n" +
+	"\x0fpb/server.proto\x12\x06tetris\x1a!google/protobuf/go_features.proto\"\xb5\x04\n" +
 	"\vGameMessage\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
 	"\n" +
@@ -343,31 +2037,121 @@ const file_pb_server_proto_rawDesc = "" +
 	"isGameOver\x12\x1f\n" +
 	"\vlines_clear\x18\x04 \x01(\x05R\n" +
 	"linesClear\x12#\n" +
-	"\x05stack\x18\x05 \x01(\v2\r.tetris.StackR\x05stack\"(\n" +
+	"\x05stack\x18\x05 \x01(\v2\r.tetris.StackR\x05stack\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\x05R\x05score\x12!\n" +
+	"\fgarbage_sent\x18\a \x01(\x05R\vgarbageSent\x12'\n" +
+	"\x0fgarbage_pending\x18\b \x01(\x05R\x0egarbagePending\x12\x12\n" +
+	"\x04room\x18\t \x01(\tR\x04room\x12\x19\n" +
+	"\bnew_room\x18\n" +
+	" \x01(\bR\anewRoom\x12\x18\n" +
+	"\aplayers\x18\v \x01(\x05R\aplayers\x12\x16\n" +
+	"\x06player\x18\f \x01(\x05R\x06player\x12)\n" +
+	"\x10garbage_received\x18\r \x01(\x05R\x0fgarbageReceived\x12&\n" +
+	"\x06target\x18\x0e \x01(\x0e2\x0e.tetris.TargetR\x06target\x12\x12\n" +
+	"\x04left\x18\x0f \x01(\bR\x04left\x12$\n" +
+	"\rauthoritative\x18\x10 \x01(\bR\rauthoritative\x12\x16\n" +
+	"\x06action\x18\x11 \x01(\tR\x06action\x12#\n" +
+	"\x05state\x18\x12 \x01(\v2\r.tetris.StateR\x05state\"\x12\n" +
+	"\x10ListGamesRequest\";\n" +
+	"\x11ListGamesResponse\x12&\n" +
+	"\x05games\x18\x01 \x03(\v2\x10.tetris.GameInfoR\x05games\"N\n" +
+	"\bGameInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x18\n" +
+	"\aplayers\x18\x02 \x03(\tR\aplayers\x12\x18\n" +
+	"\aplaying\x18\x03 \x01(\x05R\aplaying\"%\n" +
+	"\x0fSpectateRequest\x12\x12\n" +
+	"\x04game\x18\x01 \x01(\x05R\x04game\"(\n" +
 	"\x05Stack\x12\x1f\n" +
 	"\x04rows\x18\x01 \x03(\v2\v.tetris.RowR\x04rows\"\x1b\n" +
 	"\x03Row\x12\x14\n" +
-	"\x05cells\x18\x01 \x03(\tR\x05cells2M\n" +
+	"\x05cells\x18\x01 \x03(\tR\x05cells\"\xcd\x04\n" +
+	"\x05State\x12#\n" +
+	"\x05stack\x18\x01 \x01(\v2\r.tetris.StackR\x05stack\x12+\n" +
+	"\ttetromino\x18\x02 \x01(\v2\r.tetris.PieceR\ttetromino\x12!\n" +
+	"\x04next\x18\x03 \x03(\v2\r.tetris.PieceR\x04next\x12!\n" +
+	"\x04hold\x18\x04 \x01(\v2\r.tetris.PieceR\x04hold\x12\x14\n" +
+	"\x05level\x18\x05 \x01(\x05R\x05level\x12\x1f\n" +
+	"\vlines_clear\x18\x06 \x01(\x05R\n" +
+	"linesClear\x12\x14\n" +
+	"\x05score\x18\a \x01(\x05R\x05score\x12\x16\n" +
+	"\x06pieces\x18\b \x01(\x05R\x06pieces\x12,\n" +
+	"\n" +
+	"last_clear\x18\t \x01(\v2\r.tetris.ClearR\tlastClear\x129\n" +
+	"\rlines_cleared\x18\n" +
+	" \x01(\v2\x14.tetris.LinesClearedR\flinesCleared\x12 \n" +
+	"\fback_to_back\x18\v \x01(\x05R\n" +
+	"backToBack\x12\x14\n" +
+	"\x05combo\x18\f \x01(\x05R\x05combo\x12!\n" +
+	"\fgarbage_sent\x18\r \x01(\x05R\vgarbageSent\x12'\n" +
+	"\x0fgarbage_pending\x18\x0e \x01(\x05R\x0egarbagePending\x12\x12\n" +
+	"\x04mode\x18\x0f \x01(\tR\x04mode\x12\x12\n" +
+	"\x04time\x18\x10 \x01(\x03R\x04time\x12\x10\n" +
+	"\x03won\x18\x11 \x01(\bR\x03won\x12 \n" +
+	"\fis_game_over\x18\x12 \x01(\bR\n" +
+	"isGameOver\"s\n" +
+	"\x05Piece\x12\x14\n" +
+	"\x05shape\x18\x01 \x01(\tR\x05shape\x12\f\n" +
+	"\x01x\x18\x02 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x03 \x01(\x05R\x01y\x12\x17\n" +
+	"\aghost_y\x18\x04 \x01(\x05R\x06ghostY\x12\x1f\n" +
+	"\x04grid\x18\x05 \x03(\v2\v.tetris.RowR\x04grid\"4\n" +
+	"\x05Clear\x12\x14\n" +
+	"\x05lines\x18\x01 \x01(\x05R\x05lines\x12\x15\n" +
+	"\x06t_spin\x18\x02 \x01(\tR\x05tSpin\"G\n" +
+	"\fLinesCleared\x12\x12\n" +
+	"\x04rows\x18\x01 \x03(\x05R\x04rows\x12#\n" +
+	"\x05clear\x18\x02 \x01(\v2\r.tetris.ClearR\x05clear*@\n" +
+	"\x06Target\x12\x11\n" +
+	"\rTARGET_RANDOM\x10\x00\x12\x14\n" +
+	"\x10TARGET_ATTACKERS\x10\x01\x12\r\n" +
+	"\tTARGET_KO\x10\x022\xcf\x01\n" +
 	"\rTetrisService\x12<\n" +
 	"\n" +
-	"PlayTetris\x12\x13.tetris.GameMessage\x1a\x13.tetris.GameMessage\"\x00(\x010\x01B*Z github.com/Alvaroalonsobabbel/pb\x92\x03\x05\xd2>\x02\x10\x03b\beditionsp\xe8\a"
+	"PlayTetris\x12\x13.tetris.GameMessage\x1a\x13.tetris.GameMessage\"\x00(\x010\x01\x12B\n" +
+	"\tListGames\x12\x18.tetris.ListGamesRequest\x1a\x19.tetris.ListGamesResponse\"\x00\x12<\n" +
+	"\bSpectate\x12\x17.tetris.SpectateRequest\x1a\x13.tetris.GameMessage\"\x000\x01B*Z github.com/Alvaroalonsobabbel/pb\x92\x03\x05\xd2>\x02\x10\x03b\beditionsp\xe8\a"
 
-var file_pb_server_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_pb_server_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pb_server_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_pb_server_proto_goTypes = []any{
-	(*GameMessage)(nil), // 0: tetris.GameMessage
-	(*Stack)(nil),       // 1: tetris.Stack
-	(*Row)(nil),         // 2: tetris.Row
+	(Target)(0),               // 0: tetris.Target
+	(*GameMessage)(nil),       // 1: tetris.GameMessage
+	(*ListGamesRequest)(nil),  // 2: tetris.ListGamesRequest
+	(*ListGamesResponse)(nil), // 3: tetris.ListGamesResponse
+	(*GameInfo)(nil),          // 4: tetris.GameInfo
+	(*SpectateRequest)(nil),   // 5: tetris.SpectateRequest
+	(*Stack)(nil),             // 6: tetris.Stack
+	(*Row)(nil),               // 7: tetris.Row
+	(*State)(nil),             // 8: tetris.State
+	(*Piece)(nil),             // 9: tetris.Piece
+	(*Clear)(nil),             // 10: tetris.Clear
+	(*LinesCleared)(nil),      // 11: tetris.LinesCleared
 }
 var file_pb_server_proto_depIdxs = []int32{
-	1, // 0: tetris.GameMessage.stack:type_name -> tetris.Stack
-	2, // 1: tetris.Stack.rows:type_name -> tetris.Row
-	0, // 2: tetris.TetrisService.PlayTetris:input_type -> tetris.GameMessage
-	0, // 3: tetris.TetrisService.PlayTetris:output_type -> tetris.GameMessage
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	6,  // 0: tetris.GameMessage.stack:type_name -> tetris.Stack
+	0,  // 1: tetris.GameMessage.target:type_name -> tetris.Target
+	8,  // 2: tetris.GameMessage.state:type_name -> tetris.State
+	4,  // 3: tetris.ListGamesResponse.games:type_name -> tetris.GameInfo
+	7,  // 4: tetris.Stack.rows:type_name -> tetris.Row
+	6,  // 5: tetris.State.stack:type_name -> tetris.Stack
+	9,  // 6: tetris.State.tetromino:type_name -> tetris.Piece
+	9,  // 7: tetris.State.next:type_name -> tetris.Piece
+	9,  // 8: tetris.State.hold:type_name -> tetris.Piece
+	10, // 9: tetris.State.last_clear:type_name -> tetris.Clear
+	11, // 10: tetris.State.lines_cleared:type_name -> tetris.LinesCleared
+	7,  // 11: tetris.Piece.grid:type_name -> tetris.Row
+	10, // 12: tetris.LinesCleared.clear:type_name -> tetris.Clear
+	1,  // 13: tetris.TetrisService.PlayTetris:input_type -> tetris.GameMessage
+	2,  // 14: tetris.TetrisService.ListGames:input_type -> tetris.ListGamesRequest
+	5,  // 15: tetris.TetrisService.Spectate:input_type -> tetris.SpectateRequest
+	1,  // 16: tetris.TetrisService.PlayTetris:output_type -> tetris.GameMessage
+	3,  // 17: tetris.TetrisService.ListGames:output_type -> tetris.ListGamesResponse
+	1,  // 18: tetris.TetrisService.Spectate:output_type -> tetris.GameMessage
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_pb_server_proto_init() }
@@ -380,13 +2164,14 @@ func file_pb_server_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_server_proto_rawDesc), len(file_pb_server_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_pb_server_proto_goTypes,
 		DependencyIndexes: file_pb_server_proto_depIdxs,
+		EnumInfos:         file_pb_server_proto_enumTypes,
 		MessageInfos:      file_pb_server_proto_msgTypes,
 	}.Build()
 	File_pb_server_proto = out.File
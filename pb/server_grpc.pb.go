@@ -20,6 +20,8 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	TetrisService_PlayTetris_FullMethodName = "/tetris.TetrisService/PlayTetris"
+	TetrisService_ListGames_FullMethodName  = "/tetris.TetrisService/ListGames"
+	TetrisService_Spectate_FullMethodName   = "/tetris.TetrisService/Spectate"
 )
 
 // TetrisServiceClient is the client API for TetrisService service.
@@ -27,6 +29,11 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TetrisServiceClient interface {
 	PlayTetris(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[GameMessage, GameMessage], error)
+	// ListGames lists the matches being played.
+	ListGames(ctx context.Context, in *ListGamesRequest, opts ...grpc.CallOption) (*ListGamesResponse, error)
+	// Spectate streams the messages of the players of a match, starting
+	// with the last one of each, until the match is over.
+	Spectate(ctx context.Context, in *SpectateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GameMessage], error)
 }
 
 type tetrisServiceClient struct {
@@ -50,11 +57,45 @@ func (c *tetrisServiceClient) PlayTetris(ctx context.Context, opts ...grpc.CallO
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TetrisService_PlayTetrisClient = grpc.BidiStreamingClient[GameMessage, GameMessage]
 
+func (c *tetrisServiceClient) ListGames(ctx context.Context, in *ListGamesRequest, opts ...grpc.CallOption) (*ListGamesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGamesResponse)
+	err := c.cc.Invoke(ctx, TetrisService_ListGames_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tetrisServiceClient) Spectate(ctx context.Context, in *SpectateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GameMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TetrisService_ServiceDesc.Streams[1], TetrisService_Spectate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SpectateRequest, GameMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TetrisService_SpectateClient = grpc.ServerStreamingClient[GameMessage]
+
 // TetrisServiceServer is the server API for TetrisService service.
 // All implementations must embed UnimplementedTetrisServiceServer
 // for forward compatibility.
 type TetrisServiceServer interface {
 	PlayTetris(grpc.BidiStreamingServer[GameMessage, GameMessage]) error
+	// ListGames lists the matches being played.
+	ListGames(context.Context, *ListGamesRequest) (*ListGamesResponse, error)
+	// Spectate streams the messages of the players of a match, starting
+	// with the last one of each, until the match is over.
+	Spectate(*SpectateRequest, grpc.ServerStreamingServer[GameMessage]) error
 	mustEmbedUnimplementedTetrisServiceServer()
 }
 
@@ -68,6 +109,12 @@ type UnimplementedTetrisServiceServer struct{}
 func (UnimplementedTetrisServiceServer) PlayTetris(grpc.BidiStreamingServer[GameMessage, GameMessage]) error {
 	return status.Errorf(codes.Unimplemented, "method PlayTetris not implemented")
 }
+func (UnimplementedTetrisServiceServer) ListGames(context.Context, *ListGamesRequest) (*ListGamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGames not implemented")
+}
+func (UnimplementedTetrisServiceServer) Spectate(*SpectateRequest, grpc.ServerStreamingServer[GameMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Spectate not implemented")
+}
 func (UnimplementedTetrisServiceServer) mustEmbedUnimplementedTetrisServiceServer() {}
 func (UnimplementedTetrisServiceServer) testEmbeddedByValue()                       {}
 
@@ -96,13 +143,47 @@ func _TetrisService_PlayTetris_Handler(srv interface{}, stream grpc.ServerStream
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type TetrisService_PlayTetrisServer = grpc.BidiStreamingServer[GameMessage, GameMessage]
 
+func _TetrisService_ListGames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TetrisServiceServer).ListGames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TetrisService_ListGames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TetrisServiceServer).ListGames(ctx, req.(*ListGamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TetrisService_Spectate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SpectateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TetrisServiceServer).Spectate(m, &grpc.GenericServerStream[SpectateRequest, GameMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TetrisService_SpectateServer = grpc.ServerStreamingServer[GameMessage]
+
 // TetrisService_ServiceDesc is the grpc.ServiceDesc for TetrisService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var TetrisService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "tetris.TetrisService",
 	HandlerType: (*TetrisServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListGames",
+			Handler:    _TetrisService_ListGames_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "PlayTetris",
@@ -110,6 +191,11 @@ var TetrisService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Spectate",
+			Handler:       _TetrisService_Spectate_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pb/server.proto",
 }
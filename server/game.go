@@ -0,0 +1,350 @@
+package server
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"tetris/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// minPlayers and maxPlayers are the sizes a match can have.
+	minPlayers = 2
+	maxPlayers = 8
+
+	// watcherBuffer is how many messages a spectator can fall behind
+	// before the oldest ones are dropped.
+	watcherBuffer = 64
+)
+
+type game struct {
+	// id identifies the match for spectators once it starts.
+	id int32
+	// size is the number of players the match starts with.
+	size int
+	// seed deals the same tetrominoes to every player in matches the
+	// server runs.
+	seed    int64
+	players []*player
+	// watchers are the spectators of the match, and last the last
+	// message of each player, which is the first thing they get.
+	watchers []*player
+	last     map[int]*pb.GameMessage
+	// seats is the number of players that have joined so far, which
+	// gives each one their seat.
+	seats   int
+	started bool
+	// done is closed when the match is over.
+	done   chan struct{}
+	closed bool
+	mu     sync.Mutex
+}
+
+type player struct {
+	seat int
+	name string
+	// ch has the messages of the other players to send to this one.
+	ch chan *pb.GameMessage
+	// watcher is set for spectators, who never hold up the match.
+	watcher bool
+	// left is closed when the player leaves the match.
+	left chan struct{}
+	// out is set when the player tops out or leaves. players out of the
+	// match keep watching it but aren't targeted anymore.
+	out    bool
+	target pb.Target
+	// lastTarget is the seat of the last player attacked.
+	lastTarget int
+	// sent and received are the garbage lines sent and received so far.
+	sent, received int32
+	// height is the height of the stack plus the pending garbage.
+	height int
+}
+
+func newGame(size int) *game {
+	return &game{
+		size: min(max(size, minPlayers), maxPlayers),
+		seed: max(rand.Int64(), 1), //nolint: gosec
+		done: make(chan struct{}),
+		last: make(map[int]*pb.GameMessage),
+	}
+}
+
+func (g *game) join(name string) *player {
+	// join() seats a new player in the match, which starts once it's full.
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.seats++
+	p := &player{
+		seat: g.seats,
+		name: name,
+		ch:   make(chan *pb.GameMessage),
+		left: make(chan struct{}),
+	}
+	g.players = append(g.players, p)
+	g.started = len(g.players) == g.size
+	return p
+}
+
+func (g *game) watch() (*player, []*pb.GameMessage) {
+	// watch() adds a spectator to the match, which gets the messages of
+	// the players from now on, and returns the last one of each so far.
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	w := &player{
+		ch:      make(chan *pb.GameMessage, watcherBuffer),
+		left:    make(chan struct{}),
+		watcher: true,
+	}
+	g.watchers = append(g.watchers, w)
+	var last []*pb.GameMessage
+	for _, p := range g.players {
+		if m, ok := g.last[p.seat]; ok {
+			last = append(last, m)
+		}
+	}
+	return w, last
+}
+
+func (g *game) unwatch(w *player) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	close(w.left)
+	g.watchers = slices.DeleteFunc(g.watchers, func(o *player) bool { return o == w })
+}
+
+func (g *game) info() *pb.GameInfo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, len(g.players))
+	for i, p := range g.players {
+		names[i] = p.name
+	}
+	return pb.GameInfo_builder{
+		Id:      proto.Int32(g.id),
+		Players: names,
+		Playing: proto.Int32(int32(g.playing())), // nolint:gosec
+	}.Build()
+}
+
+func (g *game) abandon(p *player) bool {
+	// abandon() removes a player waiting for the match to start, and
+	// returns whether the match is left without players. it does nothing
+	// once the match has started.
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.started {
+		return false
+	}
+	for i, o := range g.players {
+		if o == p {
+			g.players = append(g.players[:i], g.players[i+1:]...)
+			break
+		}
+	}
+	return len(g.players) == 0
+}
+
+func (g *game) isStarted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.started
+}
+
+func (g *game) broadcast(from *player, gm *pb.GameMessage) {
+	// broadcast() sends the message of a player to the rest of the match,
+	// after sending its new garbage to an opponent. every player is told
+	// the garbage they've received in the messages they get.
+	g.mu.Lock()
+	g.attack(from, gm)
+	from.target = gm.GetTarget()
+	from.height = height(gm)
+	from.out = from.out || gm.GetIsGameOver()
+	msgs := make(map[*player]*pb.GameMessage, len(g.players)+len(g.watchers)-1)
+	for _, p := range g.players {
+		if p == from {
+			continue
+		}
+		m := proto.CloneOf(gm)
+		m.SetPlayer(int32(from.seat)) // nolint:gosec
+		m.SetGarbageReceived(p.received)
+		msgs[p] = m
+	}
+	g.last[from.seat] = proto.CloneOf(gm)
+	g.last[from.seat].SetPlayer(int32(from.seat)) // nolint:gosec
+	for _, w := range g.watchers {
+		msgs[w] = g.last[from.seat]
+	}
+	over := g.started && g.playing() < minPlayers
+	g.mu.Unlock()
+
+	g.send(msgs)
+	if over {
+		g.close()
+	}
+}
+
+func (g *game) leave(p *player) {
+	// leave() takes the player out of the match. if they were still
+	// playing, the rest of the match is told they left.
+	close(p.left)
+	g.mu.Lock()
+	var msgs map[*player]*pb.GameMessage
+	if g.started && !p.out {
+		msgs = make(map[*player]*pb.GameMessage, len(g.players)+len(g.watchers)-1)
+		for _, o := range slices.Concat(g.players, g.watchers) {
+			if o != p {
+				msgs[o] = pb.GameMessage_builder{
+					Name:            proto.String(p.name),
+					Player:          proto.Int32(int32(p.seat)), // nolint:gosec
+					IsGameOver:      proto.Bool(true),
+					Left:            proto.Bool(true),
+					GarbageReceived: proto.Int32(o.received),
+				}.Build()
+			}
+		}
+		// spectators joining later see the player out of the match.
+		if m, ok := g.last[p.seat]; ok {
+			m = proto.CloneOf(m)
+			m.SetIsGameOver(true)
+			m.SetLeft(true)
+			g.last[p.seat] = m
+		}
+	}
+	p.out = true
+	over := g.started && g.playing() < minPlayers
+	g.mu.Unlock()
+
+	g.send(msgs)
+	if over {
+		g.close()
+	}
+}
+
+func (g *game) send(msgs map[*player]*pb.GameMessage) {
+	for p, m := range msgs {
+		if p.watcher {
+			if !g.isClosed() {
+				p.push(m)
+			}
+			continue
+		}
+		select {
+		case p.ch <- m:
+		case <-p.left:
+		case <-g.done:
+		}
+	}
+}
+
+func (p *player) push(m *pb.GameMessage) {
+	// push() queues a message for a spectator without waiting for them,
+	// dropping the oldest one queued if they're too far behind.
+	for {
+		select {
+		case p.ch <- m:
+			return
+		default:
+		}
+		select {
+		case <-p.ch:
+		default:
+		}
+	}
+}
+
+func (g *game) attack(from *player, gm *pb.GameMessage) {
+	// attack() sends the garbage lines the player has sent since its last
+	// message to the opponent picked by its target.
+	lines := gm.GetGarbageSent() - from.sent
+	if lines <= 0 {
+		return
+	}
+	from.sent = gm.GetGarbageSent()
+	if to := g.target(from); to != nil {
+		to.received += lines
+		from.lastTarget = to.seat
+	}
+}
+
+func (g *game) target(from *player) *player {
+	// target() picks the opponent to attack among the ones still playing.
+	var opponents []*player
+	for _, p := range g.players {
+		if p != from && !p.out {
+			opponents = append(opponents, p)
+		}
+	}
+	if len(opponents) == 0 {
+		return nil
+	}
+	var picked []*player
+	switch from.target {
+	case pb.Target_TARGET_ATTACKERS:
+		for _, p := range opponents {
+			if p.lastTarget == from.seat {
+				picked = append(picked, p)
+			}
+		}
+	case pb.Target_TARGET_KO:
+		highest := 0
+		for _, p := range opponents {
+			switch {
+			case p.height > highest:
+				highest = p.height
+				picked = []*player{p}
+			case p.height == highest && highest > 0:
+				picked = append(picked, p)
+			}
+		}
+	}
+	if len(picked) == 0 {
+		picked = opponents
+	}
+	return picked[rand.IntN(len(picked))] //nolint: gosec
+}
+
+func (g *game) playing() int {
+	var n int
+	for _, p := range g.players {
+		if !p.out {
+			n++
+		}
+	}
+	return n
+}
+
+func (g *game) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	log.Printf("game instance %p is over", g)
+	close(g.done)
+	g.closed = true
+}
+
+func (g *game) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+func height(gm *pb.GameMessage) int {
+	// height() is the height of the stack of a message plus its pending
+	// garbage.
+	h := int(gm.GetGarbagePending())
+	rows := gm.GetStack().GetRows()
+	for y := len(rows) - 1; y >= 0; y-- {
+		for _, c := range rows[y].GetCells() {
+			if c != "" {
+				return h + y + 1
+			}
+		}
+	}
+	return h
+}
@@ -5,8 +5,15 @@ import (
 	"errors"
 	"io"
 	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"tetris/message"
 	"tetris/pb"
+	"tetris/tetris"
 	"time"
 
 	"google.golang.org/grpc"
@@ -16,177 +23,388 @@ import (
 )
 
 const (
-	player1 = 1
-	player2 = 2
-
 	// Default timeout for waiting for opponent.
 	defaultTimeOut = 30 * time.Second
+
+	// roomCodeChars are the characters of room codes, without the ones
+	// that are easy to mistake for each other.
+	roomCodeChars  = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	roomCodeLength = 4
 )
 
-type game struct {
-	p1Ch, p2Ch chan *pb.GameMessage
-	p1, p2     bool
-	closed     bool
-	mu         sync.Mutex
+type tetrisServer struct {
+	pb.UnimplementedTetrisServiceServer
+	// waitList is the game waiting for an opponent to be matched, matches
+	// the games waiting for more players to be matched by their size, and
+	// rooms the games waiting for players to join their room.
+	waitList *game
+	matches  map[int]*game
+	rooms    map[string]*game
+	// games are the matches being played by id, lastID being the id of
+	// the last one started.
+	games       map[int32]*game
+	lastID      int32
+	waitTimeout time.Duration
+	// authoritative servers run the games of the players, see WithAuthority.
+	authoritative bool
+	mu            sync.Mutex
 }
 
-func newGame() *game {
-	return &game{
-		p1Ch: make(chan *pb.GameMessage),
-		p2Ch: make(chan *pb.GameMessage),
-	}
+// Option configures the server.
+type Option func(*tetrisServer)
+
+// WithAuthority makes the server run the game of every player from the
+// actions they send, instead of relaying the games they play. Players
+// can't cheat by sending a game that wasn't played.
+func WithAuthority() Option {
+	return func(t *tetrisServer) { t.authoritative = true }
 }
 
-func (g *game) isStarted() bool {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	return g.p1 && g.p2
+func New(opts ...Option) pb.TetrisServiceServer {
+	t := &tetrisServer{waitTimeout: defaultTimeOut}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
-func (g *game) ready(p int) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	switch p {
-	case player1:
-		g.p1 = true
-	case player2:
-		g.p2 = true
+func (t *tetrisServer) join(room, name string, players int) (*game, *player, error) {
+	// join() seats the player in the game waiting in the room, or in the
+	// waiting list for its size if there's no room, or in a new one
+	// waiting for players. rooms are only created by newRoom(), so there
+	// is no game to join with an unknown code. the setup happens under
+	// mutex lock to prevent multiple concurrent connections creating a
+	// game each.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	g := t.waiting(room, players)
+	switch {
+	case g == nil && room != "":
+		return nil, nil, status.Errorf(codes.NotFound, "room %s not found", room)
+	case g == nil:
+		g = newGame(players)
+		t.setWaiting(room, g.size, g)
+	}
+	p := g.join(name)
+	if g.isStarted() {
+		t.setWaiting(room, g.size, nil)
+		t.start(g)
 	}
+	return g, p, nil
 }
 
-func (g *game) close(p int) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	if g.closed {
-		return
+func (t *tetrisServer) start(g *game) {
+	// start() lists a match that has just started until it's over. it
+	// must be called under mutex lock.
+	t.lastID++
+	g.id = t.lastID
+	if t.games == nil {
+		t.games = make(map[int32]*game)
 	}
-	log.Printf("game instance %p has been closed by player%d", g, p)
-	close(g.p1Ch)
-	close(g.p2Ch)
-	g.closed = true
+	t.games[g.id] = g
+	go func() {
+		<-g.done
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.games, g.id)
+	}()
 }
 
-func (g *game) isClosed() bool {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	return g.closed
+func (t *tetrisServer) abandon(room string, g *game, p *player) {
+	// abandon() removes a player that stopped waiting from its game, and
+	// the game from the waiting list once it's empty.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g.abandon(p) && t.waiting(room, g.size) == g {
+		t.setWaiting(room, g.size, nil)
+	}
 }
 
-type tetrisServer struct {
-	pb.UnimplementedTetrisServiceServer
-	waitList    *game
-	waitTimeout time.Duration
-	mu          sync.Mutex
+func (t *tetrisServer) waiting(room string, players int) *game {
+	switch {
+	case room != "":
+		return t.rooms[room]
+	case players <= minPlayers:
+		return t.waitList
+	default:
+		return t.matches[min(players, maxPlayers)]
+	}
 }
 
-func New() pb.TetrisServiceServer {
-	return &tetrisServer{waitTimeout: defaultTimeOut}
+func (t *tetrisServer) setWaiting(room string, players int, g *game) {
+	switch {
+	case room == "" && players == minPlayers:
+		t.waitList = g
+	case room == "" && g == nil:
+		delete(t.matches, players)
+	case room == "":
+		if t.matches == nil {
+			t.matches = make(map[int]*game)
+		}
+		t.matches[players] = g
+	case g == nil:
+		delete(t.rooms, room)
+	default:
+		if t.rooms == nil {
+			t.rooms = make(map[string]*game)
+		}
+		t.rooms[room] = g
+	}
 }
 
-func (t *tetrisServer) resetWL() {
+func (t *tetrisServer) newRoom(players int) string {
+	// newRoom() creates a room with a random code that's not in use and
+	// a game of the size waiting in it, and returns its code.
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.waitList = nil
+	for {
+		b := make([]byte, roomCodeLength)
+		for i := range b {
+			b[i] = roomCodeChars[rand.IntN(len(roomCodeChars))] //nolint: gosec
+		}
+		if _, ok := t.rooms[string(b)]; !ok {
+			g := newGame(players)
+			t.setWaiting(string(b), g.size, g)
+			return string(b)
+		}
+	}
 }
 
 func (t *tetrisServer) PlayTetris(stream grpc.BidiStreamingServer[pb.GameMessage, pb.GameMessage]) error {
-	var gameInstance *game
-	var player = player1
-	var name string
-	var opponentCh chan *pb.GameMessage
-
-	// The new game setup sequence happens under mutex lock to prevent
-	// multiple concurrent connections reading the wating list as nil.
-	t.mu.Lock()
-	switch t.waitList {
-	case nil:
-		gameInstance = newGame()
-		gameInstance.ready(player1)
-		t.waitList = gameInstance
-		opponentCh = gameInstance.p2Ch
-	default:
-		player = player2
-		gameInstance = t.waitList
-		gameInstance.ready(player2)
-		t.waitList = nil
-		opponentCh = gameInstance.p1Ch
-	}
-	t.mu.Unlock()
-	defer gameInstance.close(player)
-
+	// The first message has the name of the player, the room to play in
+	// and the size of the match.
 	gm, err := stream.Recv()
 	if err != nil {
 		return status.Errorf(codes.Canceled, "error receiving first stream message: %v", err)
 	}
-	name = gm.GetName()
-	log.Printf("%s (player %d) connected to game %p\n", name, player, gameInstance)
+	name := gm.GetName()
+	room := strings.ToUpper(gm.GetRoom())
+	if gm.GetNewRoom() {
+		room = t.newRoom(int(gm.GetPlayers()))
+	}
+	gameInstance, p, err := t.join(room, name, int(gm.GetPlayers()))
+	if err != nil {
+		log.Printf("%s couldn't join room %q: %v\n", name, room, err)
+		return err
+	}
+	defer gameInstance.leave(p)
+	log.Printf("%s (player %d) connected to game %p in room %q\n", name, p.seat, gameInstance, room)
 
-	// Only player 1 waits for the opponent.
-	if player == player1 {
-		log.Printf("%s (player %d) is waiting to start game %p\n", name, player, gameInstance)
-		to := time.After(t.waitTimeout)
-		for !gameInstance.isStarted() {
-			select {
-			case <-to:
-				// If player 1 times out waiting for opponent we clean up the gameInstance and waitingListID.
-				t.resetWL()
-				log.Printf("%s (player %d) timed out waiting to start game %p\n", name, player, gameInstance)
-				return status.Error(codes.DeadlineExceeded, "timeout waiting for opponent")
-			case <-stream.Context().Done():
-				t.resetWL()
-				log.Printf("%s (player %d) disconnected waiting to start game %p\n", name, player, gameInstance)
-				return status.Error(codes.Canceled, "player disconnected")
-			default:
-				time.Sleep(10 * time.Millisecond)
-			}
+	if gm.GetNewRoom() {
+		if err := stream.Send(pb.GameMessage_builder{Room: proto.String(room)}.Build()); err != nil {
+			t.abandon(room, gameInstance, p)
+			return status.Errorf(codes.Canceled, "failed to send room code for %s (player%d): %v", name, p.seat, err)
 		}
 	}
-	if err := stream.Send(pb.GameMessage_builder{IsStarted: proto.Bool(true)}.Build()); err != nil {
-		return status.Errorf(codes.Canceled, "failed to send gameMessage isStarted for %s (player%d): %v", name, player, err)
+
+	// Players wait for the match to be full.
+	log.Printf("%s (player %d) is waiting to start game %p\n", name, p.seat, gameInstance)
+	to := time.After(t.waitTimeout)
+	for !gameInstance.isStarted() {
+		select {
+		case <-to:
+			// If a player times out waiting for the match we take them out of the gameInstance,
+			// and clean up the waiting list if no one else is waiting.
+			t.abandon(room, gameInstance, p)
+			log.Printf("%s (player %d) timed out waiting to start game %p\n", name, p.seat, gameInstance)
+			return status.Error(codes.DeadlineExceeded, "timeout waiting for opponent")
+		case <-stream.Context().Done():
+			t.abandon(room, gameInstance, p)
+			log.Printf("%s (player %d) disconnected waiting to start game %p\n", name, p.seat, gameInstance)
+			return status.Error(codes.Canceled, "player disconnected")
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if err := stream.Send(pb.GameMessage_builder{
+		IsStarted:     proto.Bool(true),
+		Player:        proto.Int32(int32(p.seat)),            // nolint:gosec
+		Players:       proto.Int32(int32(gameInstance.size)), // nolint:gosec
+		Authoritative: proto.Bool(t.authoritative),
+	}.Build()); err != nil {
+		return status.Errorf(codes.Canceled, "failed to send gameMessage isStarted for %s (player%d): %v", name, p.seat, err)
+	}
+	if t.authoritative {
+		return runGame(stream, gameInstance, p)
 	}
 
-	// Receive msg from stream and send to opponent's channel.
+	// Receive msg from stream and send it to the rest of the match.
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		defer cancel()
-		ch := gameInstance.p1Ch
-		if player == player2 {
-			ch = gameInstance.p2Ch
-		}
 		for {
 			gm, err := stream.Recv()
 			if err != nil {
-				if errors.Is(err, io.EOF) {
-					return
-				}
-				st, ok := status.FromError(err)
-				if ok && st.Code() == codes.Canceled {
-					return
-				}
-				log.Printf("error receiving stream message in %s (player%d): %v", name, player, err)
+				logRecvError(p, err)
 				return
 			}
 			if gameInstance.isClosed() {
 				return
 			}
-			ch <- gm
+			gameInstance.broadcast(p, gm)
 		}
 	}()
 
-	// Receive from opponent's channel and send to stream.
+	// Receive from the rest of the match and send to stream.
 	for {
 		select {
-		case om, ok := <-opponentCh:
-			if !ok {
-				log.Printf("opponent channel closed for %s (player%d) in game %p", name, player, gameInstance)
-				return nil
+		case om := <-p.ch:
+			if err := stream.Send(om); err != nil {
+				return status.Errorf(codes.Canceled, "failed to send opponent message for %s (player%d): %v", name, p.seat, err)
 			}
+		case <-gameInstance.done:
+			log.Printf("game %p is over for %s (player%d)", gameInstance, name, p.seat)
+			return nil
+		case <-ctx.Done():
+			log.Printf("%s (player %d) disconnected from game %p", name, p.seat, gameInstance)
+			return status.Errorf(codes.Canceled, "context canceled %s (player%d): %v", name, p.seat, err)
+		}
+	}
+}
+
+func runGame(stream grpc.BidiStreamingServer[pb.GameMessage, pb.GameMessage], gameInstance *game, p *player) error {
+	// runGame() plays the game of the player on the server from the
+	// actions they send. every update of the game is sent back to the
+	// player with its state, and to the rest of the match as the message
+	// the player would have sent.
+	tg := tetris.NewGame(tetris.WithSeed(gameInstance.seed), tetris.WithLevelCap(0))
+	tg.Start(tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(1), tetris.WithGoal(tetris.FixedGoal))
+	defer tg.Stop()
+
+	// Receive the actions of the player, which take the target of the
+	// garbage with them.
+	var target atomic.Int32
+	var invalid error
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		for {
+			gm, err := stream.Recv()
+			if err != nil {
+				logRecvError(p, err)
+				return
+			}
+			a, err := tetris.ParseAction(gm.GetAction())
+			if err != nil {
+				log.Printf("invalid action from %s (player %d): %v", p.name, p.seat, err)
+				invalid = status.Error(codes.InvalidArgument, err.Error())
+				return
+			}
+			target.Store(int32(gm.GetTarget()))
+			tg.Action(a)
+		}
+	}()
+
+	// The rest of the match only needs the last message of the player,
+	// so the one waiting to be broadcast is replaced by a newer one and
+	// the game doesn't wait for the other players.
+	broadcasts := make(chan *pb.GameMessage, 1)
+	defer close(broadcasts)
+	go func() {
+		for gm := range broadcasts {
+			gameInstance.broadcast(p, gm)
+		}
+	}()
+
+	for {
+		select {
+		case u := <-tg.GetUpdate():
+			gm := message.FromGame(p.name, u)
+			gm.SetTarget(pb.Target(target.Load()))
+			select {
+			case <-broadcasts:
+			default:
+			}
+			broadcasts <- gm
+			if err := stream.Send(pb.GameMessage_builder{
+				Player:     proto.Int32(int32(p.seat)), // nolint:gosec
+				IsGameOver: proto.Bool(u.GameOver),
+				State:      message.State(u),
+			}.Build()); err != nil {
+				return status.Errorf(codes.Canceled, "failed to send the game of %s (player%d): %v", p.name, p.seat, err)
+			}
+		case om := <-p.ch:
+			tg.RemoteGarbage(om.GetGarbageReceived())
 			if err := stream.Send(om); err != nil {
-				return status.Errorf(codes.Canceled, "failed to send opponent message for %s (player%d): %v", name, player, err)
+				return status.Errorf(codes.Canceled, "failed to send opponent message for %s (player%d): %v", p.name, p.seat, err)
 			}
+		case <-gameInstance.done:
+			log.Printf("game %p is over for %s (player%d)", gameInstance, p.name, p.seat)
+			return nil
 		case <-ctx.Done():
-			log.Printf("%s (player %d) disconnected from game %p", name, player, gameInstance)
-			return status.Errorf(codes.Canceled, "context canceled %s (player%d): %v", name, player, err)
+			if invalid != nil {
+				return invalid
+			}
+			log.Printf("%s (player %d) disconnected from game %p", p.name, p.seat, gameInstance)
+			return status.Errorf(codes.Canceled, "context canceled %s (player%d)", p.name, p.seat)
+		}
+	}
+}
+
+func logRecvError(p *player, err error) {
+	// logRecvError() logs the error that ended the messages of a player,
+	// unless they just left.
+	if errors.Is(err, io.EOF) {
+		return
+	}
+	st, ok := status.FromError(err)
+	if ok && st.Code() == codes.Canceled {
+		return
+	}
+	log.Printf("error receiving stream message in %s (player%d): %v", p.name, p.seat, err)
+}
+
+func (t *tetrisServer) ListGames(context.Context, *pb.ListGamesRequest) (*pb.ListGamesResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	games := make([]*pb.GameInfo, 0, len(t.games))
+	for _, id := range slices.Sorted(maps.Keys(t.games)) {
+		games = append(games, t.games[id].info())
+	}
+	return pb.ListGamesResponse_builder{Games: games}.Build(), nil
+}
+
+func (t *tetrisServer) Spectate(req *pb.SpectateRequest, stream grpc.ServerStreamingServer[pb.GameMessage]) error {
+	t.mu.Lock()
+	gameInstance, ok := t.games[req.GetGame()]
+	t.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "game %d not found", req.GetGame())
+	}
+	w, last := gameInstance.watch()
+	defer gameInstance.unwatch(w)
+	log.Printf("spectator connected to game %p", gameInstance)
+
+	// The first message has the size of the match, like the one players get when it starts.
+	if err := stream.Send(pb.GameMessage_builder{
+		IsStarted: proto.Bool(true),
+		Players:   proto.Int32(int32(gameInstance.size)), // nolint:gosec
+	}.Build()); err != nil {
+		return status.Errorf(codes.Canceled, "failed to send gameMessage isStarted to spectator: %v", err)
+	}
+	for _, m := range last {
+		if err := stream.Send(m); err != nil {
+			return status.Errorf(codes.Canceled, "failed to send player message to spectator: %v", err)
+		}
+	}
+	for {
+		select {
+		case m := <-w.ch:
+			if err := stream.Send(m); err != nil {
+				return status.Errorf(codes.Canceled, "failed to send player message to spectator: %v", err)
+			}
+		case <-gameInstance.done:
+			// the messages queued before the match was over go first.
+			for range len(w.ch) {
+				if err := stream.Send(<-w.ch); err != nil {
+					return status.Errorf(codes.Canceled, "failed to send player message to spectator: %v", err)
+				}
+			}
+			log.Printf("game %p is over for spectator", gameInstance)
+			return nil
+		case <-stream.Context().Done():
+			log.Printf("spectator disconnected from game %p", gameInstance)
+			return status.Error(codes.Canceled, "spectator disconnected")
 		}
 	}
 }
@@ -2,11 +2,17 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"tetris/message"
 	"tetris/pb"
+	"tetris/tetris"
 	"time"
 
 	"google.golang.org/grpc"
@@ -94,6 +100,135 @@ func TestPlayTetris(t *testing.T) {
 	})
 }
 
+func TestRooms(t *testing.T) {
+	t.Run("players in a room play each other", func(t *testing.T) {
+		server := &tetrisServer{waitTimeout: time.Second}
+		lis, closer := testCustomServer(t, server)
+		defer closer()
+
+		// someone waiting for a quick match doesn't get matched with players in a room.
+		testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("quick")}.Build())
+		p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1"), NewRoom: proto.Bool(true)}.Build())
+		code := testRoom(t, p1)
+		p2 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p2"), Room: proto.String(strings.ToLower(code))}.Build())
+		testStart(t, p1)
+		testStart(t, p2)
+		testExchange(t, p1, p2)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if server.waitList == nil {
+			t.Errorf("expected the quick match player to keep waiting")
+		}
+		if len(server.rooms) != 0 {
+			t.Errorf("expected no rooms waiting, got %v", server.rooms)
+		}
+	})
+
+	t.Run("new room", func(t *testing.T) {
+		lis, closer := testServer(t)
+		defer closer()
+
+		p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1"), NewRoom: proto.Bool(true)}.Build())
+		p2 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p2"), Room: proto.String(testRoom(t, p1))}.Build())
+		testStart(t, p1)
+		testStart(t, p2)
+		testExchange(t, p1, p2)
+	})
+
+	t.Run("time out waiting in a room", func(t *testing.T) {
+		server := &tetrisServer{waitTimeout: 150 * time.Millisecond}
+		lis, closer := testCustomServer(t, server)
+		defer closer()
+
+		p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1"), NewRoom: proto.Bool(true)}.Build())
+		var err error
+		for err == nil {
+			_, err = p1.Recv()
+		}
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.DeadlineExceeded {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if len(server.rooms) != 0 {
+			t.Errorf("expected the room to be removed, got %v", server.rooms)
+		}
+	})
+
+	t.Run("unknown room", func(t *testing.T) {
+		server := &tetrisServer{waitTimeout: time.Second}
+		lis, closer := testCustomServer(t, server)
+		defer closer()
+
+		p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1"), Room: proto.String("WXYZ")}.Build())
+		_, err := p1.Recv()
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+			t.Errorf("expected NotFound, got %v", err)
+		}
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if len(server.rooms) != 0 {
+			t.Errorf("expected no room to be created, got %v", server.rooms)
+		}
+	})
+}
+
+func testRoom(t *testing.T, stream testStream) string {
+	// testRoom() returns the code of the room created by the player.
+	t.Helper()
+	gm, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("error receiving room code: %v", err)
+	}
+	if len(gm.GetRoom()) != roomCodeLength {
+		t.Fatalf("expected a room code, got %q", gm.GetRoom())
+	}
+	return gm.GetRoom()
+}
+
+type testStream = grpc.BidiStreamingClient[pb.GameMessage, pb.GameMessage]
+
+func testJoin(t *testing.T, lis *bufconn.Listener, gm *pb.GameMessage) testStream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	stream, err := pb.NewTetrisServiceClient(testClient(t, lis)).PlayTetris(ctx)
+	if err != nil {
+		t.Fatalf("error calling PlayTetris for %s: %v", gm.GetName(), err)
+	}
+	if err := stream.Send(gm); err != nil {
+		t.Fatalf("error sending first message for %s: %v", gm.GetName(), err)
+	}
+	// the first message has to be received before the next player joins.
+	time.Sleep(20 * time.Millisecond)
+	return stream
+}
+
+func testStart(t *testing.T, stream testStream) {
+	t.Helper()
+	for {
+		gm, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("error receiving message while waiting for game to start: %v", err)
+		}
+		if gm.GetIsStarted() {
+			return
+		}
+	}
+}
+
+func testExchange(t *testing.T, p1, p2 testStream) {
+	t.Helper()
+	if err := p1.Send(pb.GameMessage_builder{LinesClear: proto.Int32(7)}.Build()); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	gm, err := p2.Recv()
+	if err != nil || gm.GetLinesClear() != 7 {
+		t.Errorf("expected the opponent to receive 7 lines cleared, got %v and error %v", gm, err)
+	}
+}
+
 func testServer(t testing.TB) (*bufconn.Listener, func()) {
 	return testCustomServer(t, New())
 }
@@ -167,3 +302,249 @@ func testPlayer(t *testing.T, n int, lis *bufconn.Listener) {
 		}
 	}
 }
+
+func TestBattle(t *testing.T) {
+	lis, closer := testServer(t)
+	defer closer()
+
+	var players []testStream
+	for i := range 3 {
+		players = append(players, testJoin(t, lis, pb.GameMessage_builder{Name: proto.String(fmt.Sprintf("p%d", i+1)), Players: proto.Int32(3)}.Build()))
+	}
+	for i, p := range players {
+		gm, err := p.Recv()
+		if err != nil || !gm.GetIsStarted() || gm.GetPlayer() != int32(i+1) || gm.GetPlayers() != 3 { // nolint:gosec
+			t.Fatalf("expected p%d to start in seat %d of 3, got %v and error %v", i+1, i+1, gm, err)
+		}
+	}
+	p1, p2, p3 := players[0], players[1], players[2]
+
+	// every player gets the messages of the rest of the match, with the garbage sent to them.
+	if err := p1.Send(pb.GameMessage_builder{GarbageSent: proto.Int32(4)}.Build()); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	var received int32
+	for _, p := range []testStream{p2, p3} {
+		gm, err := p.Recv()
+		if err != nil || gm.GetPlayer() != 1 {
+			t.Fatalf("expected the message of player 1, got %v and error %v", gm, err)
+		}
+		received += gm.GetGarbageReceived()
+	}
+	if received != 4 {
+		t.Errorf("expected the 4 lines sent to be received by a single opponent, got %d", received)
+	}
+
+	// players out of the match keep watching it, and the last one playing wins.
+	if err := p3.Send(pb.GameMessage_builder{IsGameOver: proto.Bool(true)}.Build()); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	for _, p := range []testStream{p1, p2} {
+		if gm, err := p.Recv(); err != nil || gm.GetPlayer() != 3 || !gm.GetIsGameOver() {
+			t.Fatalf("expected player 3 to be out, got %v and error %v", gm, err)
+		}
+	}
+	if err := p2.CloseSend(); err != nil {
+		t.Fatalf("error leaving the match: %v", err)
+	}
+	for _, p := range []testStream{p1, p3} {
+		if gm, err := p.Recv(); err != nil || gm.GetPlayer() != 2 || !gm.GetLeft() {
+			t.Fatalf("expected player 2 to leave, got %v and error %v", gm, err)
+		}
+		if _, err := p.Recv(); !errors.Is(err, io.EOF) {
+			t.Errorf("expected the match to be over, got %v", err)
+		}
+	}
+}
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		target pb.Target
+		want   int
+	}{
+		{name: "attackers targets the player attacking", target: pb.Target_TARGET_ATTACKERS, want: 3},
+		{name: "ko targets the highest stack", target: pb.Target_TARGET_KO, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(5)
+			for i := range 5 {
+				g.join(fmt.Sprintf("p%d", i+1))
+			}
+			g.players[0].target = tt.target
+			g.players[2].lastTarget = 1
+			g.players[3].height = 12
+			g.players[4].out = true
+			g.players[4].height = 20
+			for range 10 {
+				if p := g.target(g.players[0]); p.seat != tt.want {
+					t.Fatalf("expected player %d to be targeted, got %d", tt.want, p.seat)
+				}
+			}
+		})
+	}
+
+	t.Run("random never targets players out of the match", func(t *testing.T) {
+		g := newGame(2)
+		g.join("p1")
+		g.join("p2").out = true
+		if p := g.target(g.players[0]); p != nil {
+			t.Errorf("expected no one to be targeted, got %d", p.seat)
+		}
+	})
+}
+
+func TestSlowSpectator(t *testing.T) {
+	g := newGame(2)
+	p1, p2 := g.join("p1"), g.join("p2")
+	w, _ := g.watch()
+	go func() {
+		for range p2.ch { // nolint:revive
+		}
+	}()
+
+	// a spectator that doesn't read anything doesn't hold up the players.
+	done := make(chan struct{})
+	go func() {
+		for i := range 2 * watcherBuffer {
+			g.broadcast(p1, pb.GameMessage_builder{LinesClear: proto.Int32(int32(i))}.Build()) // nolint:gosec
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the broadcasts not to wait for the spectator")
+	}
+	if gm := <-w.ch; gm.GetLinesClear() != watcherBuffer {
+		t.Errorf("expected the oldest messages to be dropped, got %d lines cleared first", gm.GetLinesClear())
+	}
+}
+
+func TestSpectate(t *testing.T) {
+	lis, closer := testServer(t)
+	defer closer()
+	client := pb.NewTetrisServiceClient(testClient(t, lis))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1")}.Build())
+	p2 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p2")}.Build())
+	testStart(t, p1)
+	testStart(t, p2)
+	testExchange(t, p1, p2)
+
+	res, err := client.ListGames(ctx, &pb.ListGamesRequest{})
+	if err != nil || len(res.GetGames()) != 1 {
+		t.Fatalf("expected a game being played, got %v and error %v", res, err)
+	}
+	if g := res.GetGames()[0]; g.GetId() != 1 || !slices.Equal(g.GetPlayers(), []string{"p1", "p2"}) || g.GetPlaying() != 2 {
+		t.Errorf("expected game 1 with p1 and p2 playing, got %v", g)
+	}
+
+	t.Run("unknown game", func(t *testing.T) {
+		stream, err := client.Spectate(ctx, pb.SpectateRequest_builder{Game: proto.Int32(2)}.Build())
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+			t.Errorf("expected NotFound, got %v", err)
+		}
+	})
+
+	// spectators get the size of the match and the last message of every player, then the rest until it's over.
+	stream, err := client.Spectate(ctx, pb.SpectateRequest_builder{Game: proto.Int32(1)}.Build())
+	if err != nil {
+		t.Fatalf("error calling Spectate: %v", err)
+	}
+	if gm, err := stream.Recv(); err != nil || !gm.GetIsStarted() || gm.GetPlayers() != 2 {
+		t.Fatalf("expected a match of 2 players, got %v and error %v", gm, err)
+	}
+	if gm, err := stream.Recv(); err != nil || gm.GetPlayer() != 1 || gm.GetLinesClear() != 7 {
+		t.Fatalf("expected the last message of player 1, got %v and error %v", gm, err)
+	}
+	if err := p2.Send(pb.GameMessage_builder{IsGameOver: proto.Bool(true)}.Build()); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	if gm, err := stream.Recv(); err != nil || gm.GetPlayer() != 2 || !gm.GetIsGameOver() {
+		t.Fatalf("expected player 2 to be out, got %v and error %v", gm, err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected the match to be over, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if res, err := client.ListGames(ctx, &pb.ListGamesRequest{}); err != nil || len(res.GetGames()) != 0 {
+		t.Errorf("expected no games being played, got %v and error %v", res, err)
+	}
+}
+
+func TestAuthority(t *testing.T) {
+	lis, closer := testCustomServer(t, New(WithAuthority()))
+	defer closer()
+
+	p1 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p1")}.Build())
+	p2 := testJoin(t, lis, pb.GameMessage_builder{Name: proto.String("p2")}.Build())
+	for _, p := range []testStream{p1, p2} {
+		if gm, err := p.Recv(); err != nil || !gm.GetIsStarted() || !gm.GetAuthoritative() {
+			t.Fatalf("expected an authoritative match to start, got %v and error %v", gm, err)
+		}
+	}
+
+	// every player gets the state of their own game, with the same tetrominoes.
+	s1, s2 := testState(t, p1), testState(t, p2)
+	if s1.Tetromino.Shape != s2.Tetromino.Shape || s1.Next[0].Shape != s2.Next[0].Shape {
+		t.Errorf("expected the same tetrominoes for both players, got %s and %s", s1.Tetromino.Shape, s2.Tetromino.Shape)
+	}
+
+	// actions move the tetromino of the game the server runs.
+	if err := p1.Send(pb.GameMessage_builder{Action: proto.String(string(tetris.MoveLeft))}.Build()); err != nil {
+		t.Fatalf("error sending action: %v", err)
+	}
+	if s := testState(t, p1); s.Tetromino.X != s1.Tetromino.X-1 {
+		t.Errorf("expected the tetromino to move left to X %d, got %d", s1.Tetromino.X-1, s.Tetromino.X)
+	}
+
+	// a made up game isn't an action, which takes the player out of the match.
+	if err := p1.Send(pb.GameMessage_builder{LinesClear: proto.Int32(40)}.Build()); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+	for {
+		_, err := p1.Recv()
+		if err == nil {
+			continue
+		}
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument, got %v", err)
+		}
+		break
+	}
+	for {
+		gm, err := p2.Recv()
+		if err != nil {
+			t.Fatalf("expected player 1 to leave, got error %v", err)
+		}
+		if gm.GetPlayer() == 1 && gm.GetLinesClear() == 40 {
+			t.Errorf("expected the made up game not to be sent, got %v", gm)
+		}
+		if gm.GetLeft() {
+			break
+		}
+	}
+}
+
+func testState(t *testing.T, stream testStream) *tetris.Tetris {
+	// testState() returns the next state of the game of the player,
+	// skipping the messages of the opponents.
+	t.Helper()
+	for {
+		gm, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("error receiving the state of the game: %v", err)
+		}
+		if !gm.HasState() {
+			continue
+		}
+		return message.Game(gm.GetState())
+	}
+}
@@ -0,0 +1,94 @@
+package tetris
+
+import "time"
+
+// Engine runs a game synchronously: there are no goroutines, tickers
+// nor animations, the game only moves forward when it's told to. It
+// shares the rules of Game, and it's meant for bots, fuzzers and
+// benchmarks that play many games as fast as possible.
+//
+// The time of the game comes from WithTimeSource, the real time by
+// default, and modes with a time limit end on the first Step or Tick
+// once it's up.
+type Engine struct {
+	game *Game
+}
+
+// NewEngine creates an engine with a new game ready to be played.
+func NewEngine(opts ...Option) *Engine {
+	o := newOptions(opts...)
+	g := &Game{
+		tetris:   newTetris(o),
+		ticker:   noTicker{},
+		clock:    noTicker{},
+		limit:    noTicker{},
+		opts:     o,
+		gameOpts: o,
+		headless: true,
+	}
+	g.setTime()
+	g.Subscribe(g.recordEvent)
+	if g.now == nil {
+		g.now = time.Now
+	}
+	g.started = g.now()
+	g.newReplay()
+	g.spawn()
+	return &Engine{game: g}
+}
+
+// Step applies an action to the game and returns whether it succeeded
+// in moving, rotating or holding the tetromino. A DropDown locks it
+// straight away.
+func (e *Engine) Step(a Action) bool {
+	if e.over() {
+		return false
+	}
+	return e.game.step(a)
+}
+
+// Tick moves the game forward as a gravity tick would: the tetromino
+// falls, or locks if it's resting on the stack. After a line clear there
+// is no tetromino until the next Tick removes the lines and spawns one.
+func (e *Engine) Tick() {
+	if e.over() {
+		return
+	}
+	e.game.tick()
+}
+
+func (e *Engine) over() bool {
+	// over() reports whether the game is over, ending it first if the
+	// time limit of the mode is up, as the ticker of a Game would.
+	if e.game.tetris.GameOver {
+		return true
+	}
+	if l := e.game.tetris.mode().limit(); l > 0 && e.game.elapsed() >= l {
+		e.game.timeUp()
+		return true
+	}
+	return false
+}
+
+// RemoteGarbage sets the garbage lines the opponent has sent so far, see
+// Game.RemoteGarbage.
+func (e *Engine) RemoteGarbage(i int32) {
+	e.game.RemoteGarbage(i)
+}
+
+// Subscribe calls h with the events of the game from the next Step or
+// Tick on. The first tetromino has already spawned when the engine is
+// created, see Snapshot.
+func (e *Engine) Subscribe(h EventHandler) {
+	e.game.Subscribe(h)
+}
+
+// Snapshot returns a copy of the current state of the game.
+func (e *Engine) Snapshot() *Tetris {
+	return e.game.read()
+}
+
+// Replay returns the replay of the game.
+func (e *Engine) Replay() *Replay {
+	return e.game.Replay()
+}
@@ -0,0 +1,102 @@
+package tetris_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"tetris/tetris"
+	"time"
+)
+
+func TestEngine(t *testing.T) {
+	t.Run("ticks make the tetromino fall and lock", func(t *testing.T) {
+		e := tetris.NewEngine(tetris.WithSeed(1))
+		s := e.Snapshot()
+		y, ghostY := s.Tetromino.Y, s.Tetromino.GhostY
+		e.Tick()
+		if got := e.Snapshot().Tetromino.Y; got != y-1 {
+			t.Errorf("wanted tetromino to fall to Y %d, got %d", y-1, got)
+		}
+		// it lands on the ghost and locks on the next tick.
+		for range y - ghostY {
+			e.Tick()
+		}
+		var cells int
+		for _, row := range e.Snapshot().Stack {
+			for _, c := range row {
+				if c != "" {
+					cells++
+				}
+			}
+		}
+		if cells != 4 {
+			t.Errorf("wanted tetromino to lock in the stack, got %d cells", cells)
+		}
+	})
+
+	t.Run("games with the same seed and actions are the same", func(t *testing.T) {
+		play := func() *tetris.Tetris {
+			e := tetris.NewEngine(tetris.WithSeed(42))
+			r := rand.New(rand.NewSource(42)) //nolint: gosec
+			actions := []tetris.Action{tetris.MoveLeft, tetris.MoveRight, tetris.RotateRight, tetris.RotateLeft, tetris.Hold, tetris.DropDown}
+			for range 500 {
+				e.Step(actions[r.Intn(len(actions))])
+				e.Tick()
+			}
+			s := e.Snapshot()
+			s.Time = 0
+			return s
+		}
+		if a, b := play(), play(); !reflect.DeepEqual(a, b) {
+			t.Errorf("wanted the same game, got %+v and %+v", a, b)
+		}
+	})
+
+	t.Run("the game is over when the stack tops out", func(t *testing.T) {
+		e := tetris.NewEngine(tetris.WithSeed(1))
+		for range 100 {
+			e.Step(tetris.DropDown)
+		}
+		if !e.Snapshot().GameOver {
+			t.Fatalf("wanted game to be over")
+		}
+		if e.Step(tetris.MoveLeft) {
+			t.Errorf("wanted actions to fail once the game is over")
+		}
+		if r := e.Replay(); r.Seed != 1 || len(r.Events) == 0 {
+			t.Errorf("wanted the game to be recorded, got seed %d with %d events", r.Seed, len(r.Events))
+		}
+	})
+
+	t.Run("ultra is won when the time is up", func(t *testing.T) {
+		now := time.Now()
+		e := tetris.NewEngine(tetris.WithSeed(1), tetris.WithMode(tetris.Ultra), tetris.WithTimeSource(func() time.Time { return now }))
+		now = now.Add(2*time.Minute - time.Millisecond)
+		e.Tick()
+		if s := e.Snapshot(); s.GameOver || s.TimeLeft() != time.Millisecond {
+			t.Fatalf("wanted game to go on with 1ms left, got game over %t with %v left", s.GameOver, s.TimeLeft())
+		}
+		now = now.Add(time.Millisecond)
+		if e.Step(tetris.MoveLeft) {
+			t.Errorf("wanted actions to fail once the time is up")
+		}
+		if s := e.Snapshot(); !s.GameOver || !s.Won {
+			t.Errorf("wanted game to be won and over, got won %t and game over %t", s.Won, s.GameOver)
+		}
+		if r := e.Replay(); r.Events[len(r.Events)-1].Kind != tetris.ReplayTimeUp {
+			t.Errorf("wanted the time up to be recorded, got %v", r.Events[len(r.Events)-1])
+		}
+	})
+}
+
+func BenchmarkEngine(b *testing.B) {
+	actions := []tetris.Action{tetris.MoveLeft, tetris.MoveRight, tetris.RotateRight, tetris.MoveDown, tetris.DropDown}
+	r := rand.New(rand.NewSource(1)) //nolint: gosec
+	for b.Loop() {
+		e := tetris.NewEngine(tetris.WithSeed(r.Int63()))
+		for !e.Snapshot().GameOver {
+			e.Step(actions[r.Intn(len(actions))])
+			e.Tick()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tetris
+
+// Event is something that happened in a game. It's one of PieceSpawned,
+// PieceLocked, *LinesCleared, LevelUp, HoldUsed, GameOver or
+// GarbageReceived.
+type Event interface {
+	event()
+}
+
+// EventHandler is called with the events of a game as they happen. It
+// runs on the goroutine of the game, so it must not block nor call the
+// game back.
+type EventHandler func(Event)
+
+// PieceSpawned is sent when a tetromino enters the matrix, including
+// the one swapped in from the hold slot.
+type PieceSpawned struct {
+	Shape Shape
+}
+
+// PieceLocked is sent when a tetromino locks in the stack.
+type PieceLocked struct {
+	Shape Shape
+	// X and Y are the position of the tetromino when it locked.
+	X, Y  int
+	TSpin TSpin
+}
+
+// LevelUp is sent when the player reaches a new level.
+type LevelUp struct {
+	Level int
+}
+
+// HoldUsed is sent when the tetromino is moved to the hold slot.
+type HoldUsed struct {
+	Shape Shape
+}
+
+// GameOver is sent when the game ends, after which no more events are
+// sent.
+type GameOver struct {
+	Won   bool
+	Score int
+}
+
+// GarbageReceived is sent when garbage lines are received from the
+// opponent, before they rise into the stack.
+type GarbageReceived struct {
+	Lines int
+}
+
+func (PieceSpawned) event()    {}
+func (PieceLocked) event()     {}
+func (*LinesCleared) event()   {}
+func (LevelUp) event()         {}
+func (HoldUsed) event()        {}
+func (GameOver) event()        {}
+func (GarbageReceived) event() {}
+
+// Subscribe calls h with the events of the game from the next one on.
+// It can be called while the game is running.
+func (g *Game) Subscribe(h EventHandler) {
+	g.handlersMu.Lock()
+	defer g.handlersMu.Unlock()
+	g.handlers = append(g.handlers, h)
+}
+
+func (g *Game) emit(e Event) {
+	g.handlersMu.Lock()
+	handlers := g.handlers
+	g.handlersMu.Unlock()
+	for _, h := range handlers {
+		h(e)
+	}
+}
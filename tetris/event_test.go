@@ -0,0 +1,91 @@
+package tetris
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	engine := func() (*Engine, *[]Event) {
+		e := NewEngine(WithSeed(1))
+		var events []Event
+		e.Subscribe(func(e Event) { events = append(events, e) })
+		return e, &events
+	}
+
+	t.Run("lock, line clear and level up", func(t *testing.T) {
+		e, events := engine()
+		te := e.game.tetris
+		te.LinesClear = 9
+		te.Tetromino = shapeMap[I]()
+		fillRows(te, 0)
+		x, y := te.Tetromino.X, te.Tetromino.Y+te.dropDownDelta()
+		next := te.Next[0].Shape
+
+		e.Step(DropDown)
+		want := []Event{
+			PieceLocked{Shape: I, X: x, Y: y},
+			&LinesCleared{Rows: []int{0}, Clear: Clear{Lines: 1}},
+		}
+		if !reflect.DeepEqual(*events, want) {
+			t.Errorf("wanted events %v on lock, got %v", want, *events)
+		}
+
+		*events = nil
+		e.Tick()
+		want = []Event{LevelUp{Level: 2}, PieceSpawned{Shape: next}}
+		if !reflect.DeepEqual(*events, want) {
+			t.Errorf("wanted events %v after the line clear delay, got %v", want, *events)
+		}
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		e, events := engine()
+		s := e.Snapshot()
+		e.Step(Hold)
+		want := []Event{HoldUsed{Shape: s.Tetromino.Shape}, PieceSpawned{Shape: s.Next[0].Shape}}
+		if !reflect.DeepEqual(*events, want) {
+			t.Errorf("wanted events %v, got %v", want, *events)
+		}
+	})
+
+	t.Run("garbage is received once", func(t *testing.T) {
+		e, events := engine()
+		e.RemoteGarbage(3)
+		e.Tick()
+		e.Tick()
+		want := []Event{GarbageReceived{Lines: 3}}
+		if !reflect.DeepEqual(*events, want) {
+			t.Errorf("wanted events %v, got %v", want, *events)
+		}
+	})
+
+	t.Run("game over", func(t *testing.T) {
+		// the next tetromino can't spawn once the current one locks.
+		e, events := engine()
+		te := e.game.tetris
+		te.Tetromino = shapeMap[J]()
+		te.Tetromino.X = 7
+		for x := 3; x < 6; x++ {
+			te.Stack[18][x] = J
+		}
+		e.Step(DropDown)
+		got := (*events)[len(*events)-1]
+		if want := (GameOver{Score: e.Snapshot().Score}); got != want {
+			t.Errorf("wanted last event %v, got %v", want, got)
+		}
+	})
+	t.Run("subscribing while the game runs", func(t *testing.T) {
+		g, _ := NewTestGame(NewTestTetris(J))
+		g.Start()
+		<-g.GetUpdate()
+		got := make(chan Event, 10)
+		g.Subscribe(func(e Event) { got <- e })
+		go g.Action(DropDown)
+		<-g.GetUpdate()
+		if e := <-got; e != (PieceLocked{Shape: J, X: 3, Y: 1}) {
+			t.Errorf("wanted the lock of the tetromino, got %v", e)
+		}
+		g.Stop()
+	})
+}
@@ -0,0 +1,19 @@
+package tetris
+
+// the fixtures of the tests that the tests of package tetris_test use too.
+var (
+	NewMockTicker = newMockTicker
+	FillRows      = fillRows
+)
+
+func fillRows(t *Tetris, rows ...int) {
+	// fillRows() fills the rows of the stack of t but for columns 3 to 6,
+	// which an I tetromino dropped flat completes.
+	for _, y := range rows {
+		for x := range 10 {
+			if x < 3 || x > 6 {
+				t.Stack[y][x] = J
+			}
+		}
+	}
+}
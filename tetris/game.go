@@ -2,8 +2,9 @@ package tetris
 
 import (
 	"context"
-	"math"
+	"fmt"
 	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,6 +18,34 @@ const (
 	DropDown    Action = "drop"      // Drops the Tetromino down the stack.
 	RotateRight Action = "rotatecw"  // Rotates the Tetromino clockwise.
 	RotateLeft  Action = "rotateccw" // Rotates the Tetromino counter-clockwise.
+	Hold        Action = "hold"      // Swaps the Tetromino with the one in the hold slot.
+)
+
+// Actions returns the actions a player can make.
+func Actions() []Action {
+	return []Action{MoveLeft, MoveRight, MoveDown, DropDown, RotateRight, RotateLeft, Hold}
+}
+
+// ParseAction returns the Action named s.
+func ParseAction(s string) (Action, error) {
+	a := Action(s)
+	if !slices.Contains(Actions(), a) {
+		return "", fmt.Errorf("unknown action %q, available: %v", s, Actions())
+	}
+	return a, nil
+}
+
+const (
+	// https://tetris.wiki/Lock_delay
+	lockDelay     = 500 * time.Millisecond
+	maxLockResets = 15
+
+	// clockRate is how often timed modes send an update for the clock.
+	clockRate = 100 * time.Millisecond
+	// lineClearDelay is the time the complete lines stay in the stack
+	// before they are removed and the next tetromino spawns.
+	// https://tetris.wiki/ARE
+	lineClearDelay = 320 * time.Millisecond
 )
 
 type Ticker interface {
@@ -37,33 +66,132 @@ func (t *timeTicker) C() <-chan time.Time   { return t.ticker.C }
 func (t *timeTicker) Stop()                 { t.ticker.Stop() }
 func (t *timeTicker) Reset(d time.Duration) { t.ticker.Reset(d) }
 
+// noTicker never ticks.
+type noTicker struct{}
+
+func (noTicker) C() <-chan time.Time { return nil }
+func (noTicker) Stop()               {}
+func (noTicker) Reset(time.Duration) {}
+
 type Game struct {
-	updateCh    chan *Tetris
-	actionCh    chan Action
-	cancel      context.CancelFunc
-	tetris      *Tetris
-	ticker      Ticker
-	remoteLines atomic.Int32
+	updateCh      chan *Tetris
+	actionCh      chan Action
+	pauseCh       chan bool
+	cancel        context.CancelFunc
+	tetris        *Tetris
+	ticker        Ticker
+	remoteGarbage atomic.Int32
+	opts          *options
+	// gameOpts are the options of the game started last, which are opts
+	// with the ones given to Start applied on top.
+	gameOpts *options
+
+	// ctx is canceled when the game is stopped, and done is closed once
+	// the game started last is over, see Action.
+	ctx  context.Context
+	done atomic.Value
+
+	// clock refreshes the elapsed time in timed modes and limit ends
+	// the game in modes with a time limit. started is when the game
+	// started according to now, and pausedAt when it was last paused.
+	clock    Ticker
+	limit    Ticker
+	now      func() time.Time
+	started  time.Time
+	pausedAt time.Time
+	// tickAt is when the ticker is due to tick next, and tickLeft the
+	// time it had left when the game was paused, which it gets back when
+	// it's resumed. resumed is true until the ticker is set back to its
+	// period, at the latest on the first tick after resuming.
+	tickAt   time.Time
+	tickLeft time.Duration
+	resumed  bool
+	// clearTime is the time of the last lock that completed lines, and
+	// finished is true once they reach the goal of the mode, which is
+	// when the clock stops.
+	clearTime time.Duration
+	finished  bool
+
+	replay     *Replay
+	handlers   []EventHandler
+	handlersMu sync.Mutex
+	// headless games don't send updates, see Engine.
+	headless bool
+
+	// lockResets counts the moves that reset the lock delay since the
+	// tetromino reached its lowest row.
+	lockResets int
+	lowestY    int
 }
 
-func NewGame() *Game {
-	return &Game{
+func NewGame(opts ...Option) *Game {
+	o := newOptions(opts...)
+	g := &Game{
 		updateCh: make(chan *Tetris),
 		actionCh: make(chan Action),
-		tetris:   newTetris(),
+		pauseCh:  make(chan bool),
+		tetris:   newTetris(o),
 		ticker:   newTimeTicker(),
+		opts:     o,
+		gameOpts: o,
+		clock:    newTimeTicker(),
+		limit:    newTimeTicker(),
+		now:      time.Now,
 	}
+	g.setTime()
+	g.Subscribe(g.recordEvent)
+	return g
 }
 
-func (g *Game) Start() {
-	if g.tetris.GameOver {
-		g.tetris = newTetris()
+// Start starts the game, or a new one if it's over. Options given to
+// Start are applied on top of the ones the game was created with for the
+// new game only, and always start a new game.
+func (g *Game) Start(opts ...Option) {
+	if g.tetris.GameOver || len(opts) > 0 {
+		o := *g.opts
+		for _, opt := range opts {
+			opt(&o)
+		}
+		g.gameOpts = &o
+		g.tetris = newTetris(g.gameOpts)
+		g.setTime()
 	}
-	go g.listen()
+	g.remoteGarbage.Store(0)
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	done := make(chan struct{})
+	g.done.Store(done)
+	go g.listen(done)
 }
 
+func (g *Game) setTime() {
+	// setTime() replaces the real time tickers and time source of the
+	// game with the ones given as options.
+	if g.gameOpts.ticker != nil {
+		g.ticker, g.clock, g.limit = g.gameOpts.ticker, noTicker{}, noTicker{}
+	}
+	if g.gameOpts.now != nil {
+		g.now = g.gameOpts.now
+	}
+}
+
+// Stop ends the game and waits for it to be over, so its replay is
+// complete once it returns.
 func (g *Game) Stop() {
+	if g.cancel != nil {
+		g.cancel()
+	}
+	if done, _ := g.done.Load().(chan struct{}); done != nil {
+		<-done
+	}
+	g.stop()
+}
+
+func (g *Game) stop() {
+	// stop() ends the game without waiting for it, as the game itself
+	// does when it's over.
 	g.ticker.Stop()
+	g.clock.Stop()
+	g.limit.Stop()
 	if !g.tetris.GameOver {
 		g.tetris.GameOver = true
 	}
@@ -72,106 +200,369 @@ func (g *Game) Stop() {
 	}
 }
 
+// Action sends an action to the game. Actions sent once the game is over
+// are dropped.
 func (g *Game) Action(a Action) {
-	g.actionCh <- a
+	done, _ := g.done.Load().(chan struct{})
+	select {
+	case g.actionCh <- a:
+	case <-done:
+	}
+}
+
+// Pause freezes the game until Resume is called. Actions are ignored
+// while the game is paused, and so is Pause once the game is over.
+func (g *Game) Pause() {
+	g.pauseGame(true)
+}
+
+// Resume resumes a paused game.
+func (g *Game) Resume() {
+	g.pauseGame(false)
+}
+
+func (g *Game) pauseGame(p bool) {
+	done, _ := g.done.Load().(chan struct{})
+	select {
+	case g.pauseCh <- p:
+	case <-done:
+	}
 }
 
 func (g *Game) GetUpdate() <-chan *Tetris {
 	return g.updateCh
 }
 
-func (g *Game) RemoteLines(i int32) {
-	g.remoteLines.Store(i)
+// RemoteGarbage sets the garbage lines the opponent has sent so far.
+// The ones not received yet wait to rise into the stack.
+func (g *Game) RemoteGarbage(i int32) {
+	g.remoteGarbage.Store(i)
 }
 
-func (g *Game) listen() {
-	var ctx context.Context
-	ctx, g.cancel = context.WithCancel(context.Background())
+func (g *Game) listen(done chan struct{}) {
+	defer close(done)
 	defer g.cancel()
-	g.ticker.Reset(g.setTime())
-	g.updateCh <- g.tetris.read()
+	g.started = g.now()
+	g.finished = false
+	g.newReplay()
+	g.startClock(0)
+	g.spawn()
+	g.update()
 	for {
 		select {
+		case <-g.clock.C():
+			// nothing to do but sending the update with the current time.
+		case <-g.limit.C():
+			g.timeUp()
 		case <-g.ticker.C():
-			g.ticker.Reset(g.setTime())
-			if g.tetris.isCollision(0, -1, g.tetris.Tetromino) {
-				g.next()
-			} else {
-				g.tetris.action(MoveDown)
-			}
+			g.tick()
+		case p := <-g.pauseCh:
+			g.pause(p)
 		case a := <-g.actionCh:
-			g.tetris.action(a)
-			if a == DropDown {
-				// drop down doesn't wait for the tick to finish the round
-				g.next()
+			if g.tetris.Paused {
+				continue
 			}
-		case <-ctx.Done():
+			g.step(a)
+		case <-g.ctx.Done():
 			return
 		}
 		if g.tetris != nil && !g.tetris.GameOver {
-			g.updateCh <- g.tetris.read()
+			g.update()
 		}
 	}
 }
 
+func (g *Game) update() {
+	// update() sends the state of the game, unless the game is stopped
+	// before anyone reads it.
+	select {
+	case g.updateCh <- g.read():
+	case <-g.ctx.Done():
+	}
+}
+
+func (g *Game) tick() {
+	// tick() moves the game forward when the ticker ticks.
+	if g.resumed {
+		g.setTicker()
+	}
+	g.receiveGarbage()
+	g.record(ReplayEvent{Kind: ReplayTick})
+	if g.tetris.LinesCleared != nil {
+		// the line clear delay is over.
+		g.removeLines()
+		g.endRound()
+		return
+	}
+	if g.tetris.isGrounded() {
+		// the lock delay has expired.
+		g.next()
+		return
+	}
+	g.fall()
+	g.lockDelay(false)
+}
+
+func (g *Game) step(a Action) bool {
+	// step() applies the action to the game and returns whether it
+	// succeeded.
+	g.receiveGarbage()
+	g.record(ReplayEvent{Kind: ReplayAction, Action: a})
+	if g.tetris.Tetromino == nil {
+		// there's nothing to move during the line clear delay.
+		return false
+	}
+	grounded := g.tetris.isGrounded()
+	ok := g.tetris.action(a)
+	switch {
+	case a == DropDown:
+		// drop down doesn't wait for the tick to finish the round
+		g.next()
+	case a == Hold && ok:
+		g.emit(HoldUsed{Shape: g.tetris.HoldTetromino.Shape})
+		if g.tetris.isBlockedOut() {
+			g.end(false)
+			return ok
+		}
+		g.spawn()
+	case ok:
+		g.lockDelay(grounded)
+	}
+	return ok
+}
+
+func (g *Game) receiveGarbage() {
+	// receiveGarbage() receives the garbage the opponent has sent since
+	// the last tick or action. it's recorded for the replay to receive
+	// it at the same point of the game.
+	n := g.remoteGarbage.Load()
+	if lines := g.tetris.receiveGarbage(int(n)); lines > 0 {
+		g.record(ReplayEvent{Kind: ReplayGarbage, Garbage: n})
+		g.emit(GarbageReceived{Lines: lines})
+	}
+}
+
+func (g *Game) startClock(elapsed time.Duration) {
+	// startClock() starts the tickers of timed modes. the time limit
+	// discounts the time the game has already been running.
+	if g.tetris.mode().timed() {
+		g.clock.Reset(clockRate)
+	} else {
+		g.clock.Stop()
+	}
+	if l := g.tetris.mode().limit(); l > 0 {
+		g.limit.Reset(l - elapsed)
+	} else {
+		g.limit.Stop()
+	}
+}
+
+func (g *Game) pause(p bool) {
+	// pause() stops every ticker of the game while it's paused, so
+	// nothing moves and the clock doesn't run, and gives back the time
+	// spent paused when it's resumed.
+	if p == g.tetris.Paused {
+		return
+	}
+	g.tetris.Paused = p
+	if p {
+		g.pausedAt = g.now()
+		g.tickLeft = g.tickAt.Sub(g.pausedAt)
+		g.ticker.Stop()
+		g.clock.Stop()
+		g.limit.Stop()
+		return
+	}
+	g.startClock(g.pausedAt.Sub(g.started))
+	g.started = g.started.Add(g.now().Sub(g.pausedAt))
+	g.resetTicker(g.tickLeft)
+	g.resumed = true
+}
+
 func (g *Game) next() {
 	g.ticker.Stop()
+	tSpin := g.tetris.tSpin()
+	t := g.tetris.Tetromino
 	g.tetris.toStack()
-	g.clearLines()
+	g.emit(PieceLocked{Shape: t.Shape, X: t.X, Y: t.Y, TSpin: tSpin})
+	if g.clearLines(tSpin) {
+		// the round ends after the line clear delay.
+		g.clearTime = g.elapsed()
+		g.setTicker()
+		return
+	}
+	if !g.tetris.riseGarbage() {
+		// the garbage pushed the stack over the top.
+		g.end(false)
+		return
+	}
+	g.endRound()
+}
+
+func (g *Game) endRound() {
+	// endRound() ends the round of the locked tetromino, either ending
+	// the game or spawning the next tetromino.
+	level := g.tetris.Level
 	g.tetris.setLevel()
-	if g.tetris.isGameOver() {
-		g.updateCh <- g.tetris.read()
-		g.Stop()
+	if g.tetris.Level > level {
+		g.emit(LevelUp{Level: g.tetris.Level})
+	}
+	if won := g.tetris.mode().won(g.tetris); won || g.tetris.isGameOver() {
+		// the goal is reached when the lines are cleared, not after the
+		// line clear delay.
+		g.finished = won
+		g.end(won)
 		return
 	}
 	g.tetris.setTetromino()
-	g.ticker.Reset(g.setTime())
+	g.spawn()
+}
+
+func (g *Game) timeUp() {
+	// timeUp() ends the game when the time is up, which is the goal of
+	// modes with a time limit.
+	g.record(ReplayEvent{Kind: ReplayTimeUp})
+	g.end(true)
+}
+
+func (g *Game) end(won bool) {
+	// end() sends the last update of the game and stops it.
+	g.tetris.Won = won
+	g.emit(GameOver{Won: won, Score: g.tetris.Score})
+	g.tetris.GameOver = true
+	if !g.headless {
+		g.update()
+	}
+	g.stop()
+}
+
+func (g *Game) spawn() {
+	// spawn() starts the round of a new tetromino.
+	// at 20G it lands as soon as it spawns.
+	if g.tetris.Tetromino != nil {
+		g.emit(PieceSpawned{Shape: g.tetris.Tetromino.Shape})
+	}
+	if _, rows := g.gravity(); rows >= maxGravity {
+		g.fall()
+	}
+	g.lockResets = 0
+	if g.tetris.Tetromino != nil {
+		g.lowestY = g.tetris.Tetromino.bottom()
+	}
+	g.setTicker()
+}
+
+func (g *Game) lockDelay(wasGrounded bool) {
+	// https://tetris.wiki/Lock_delay
+	// once the tetromino touches the stack it locks after lockDelay.
+	// moving or rotating it while grounded resets the delay up to
+	// maxLockResets times. the count starts over every time the
+	// tetromino reaches a row lower than any before.
+	if g.tetris.Tetromino == nil {
+		return
+	}
+	if b := g.tetris.Tetromino.bottom(); b < g.lowestY {
+		g.lowestY = b
+		g.lockResets = 0
+	}
+	grounded := g.tetris.isGrounded()
+	switch {
+	case !wasGrounded && !grounded:
+		// the tetromino keeps falling at gravity's pace.
+		return
+	case !grounded:
+		// moved off the stack, it falls at gravity's pace again.
+		g.lockResets++
+	case g.lockResets >= maxLockResets && !wasGrounded:
+		// out of resets, landing again on a row it had already reached
+		// locks it straight away.
+		g.next()
+		return
+	case wasGrounded && g.lockResets >= maxLockResets:
+		// out of resets, the running lock delay won't be extended.
+		return
+	case wasGrounded:
+		g.lockResets++
+	}
+	g.setTicker()
 }
 
-func (g *Game) clearLines() {
-	complete := make(map[int][]Shape)
+func (g *Game) setTicker() {
+	// setTicker() resets the ticker to lock the tetromino after the lock
+	// delay if it's grounded, or to keep it falling otherwise. between
+	// rounds it waits for the line clear delay.
+	if g.tetris.LinesCleared != nil {
+		g.resetTicker(lineClearDelay)
+		return
+	}
+	if g.tetris.isGrounded() {
+		g.resetTicker(lockDelay)
+		return
+	}
+	d, _ := g.gravity()
+	g.resetTicker(d)
+}
+
+func (g *Game) resetTicker(d time.Duration) {
+	// resetTicker() makes the ticker tick after d, which is at least
+	// the shortest time a ticker can wait.
+	d = max(d, time.Nanosecond)
+	g.resumed = false
+	g.tickAt = g.now().Add(d)
+	g.ticker.Reset(d)
+}
+
+func (g *Game) clearLines(tSpin TSpin) bool {
+	// clearLines() scores the lock and returns whether it completed any
+	// lines, which stay in the stack until the line clear delay is over.
 	var l []int
 	for i, x := range g.tetris.Stack {
 		if !slices.Contains(x, "") {
-			complete[i] = x
 			l = append(l, i)
 		}
 	}
+	c := Clear{Lines: len(l), TSpin: tSpin}
+	g.tetris.chain(c)
+	g.tetris.score(c)
+	g.tetris.attack(c)
+	g.tetris.LastClear = c
 	if len(l) == 0 {
-		return
-	}
-
-	for i := range 8 {
-		if i%2 == 0 {
-			for _, v := range l {
-				g.tetris.Stack[v] = make([]Shape, 10)
-			}
-		} else {
-			for k, v := range complete {
-				g.tetris.Stack[k] = v
-			}
-		}
-
-		g.updateCh <- g.tetris.read()
-		time.Sleep(40 * time.Millisecond)
+		return false
 	}
+	g.tetris.LinesCleared = &LinesCleared{Rows: l, Clear: c}
+	g.emit(g.tetris.LinesCleared.copy())
+	return true
+}
 
+func (g *Game) removeLines() {
+	// removeLines() removes the complete lines from the stack once the
+	// line clear delay is over.
+	l := g.tetris.LinesCleared.Rows
 	// remove complete lines in reverse order to avoid index shift issues.
 	for i := len(l) - 1; i >= 0; i-- {
 		g.tetris.Stack = append(g.tetris.Stack[:l[i]], g.tetris.Stack[l[i]+1:]...)
 		g.tetris.Stack = append(g.tetris.Stack, make([]Shape, 10))
 	}
-
 	g.tetris.LinesClear += len(l)
+	g.tetris.LinesCleared = nil
 }
 
-func (g *Game) setTime() time.Duration {
-	// setTime() sets the duration for the ticker that will progress the
-	// tetromino further down the stack. Based on https://tetris.wiki/Marathon
-	//
-	// Time = (0.8-((Level-1)*0.007))^(Level-1)
-	t := g.tetris.Level + int(g.remoteLines.Load()) - 1
-	seconds := math.Pow(0.8-float64(t)*0.007, float64(t))
+func (g *Game) read() *Tetris {
+	// read() returns a copy of the current Tetris status with the time
+	// elapsed since the game started.
+	g.tetris.Time = g.elapsed()
+	return g.tetris.read()
+}
 
-	return time.Duration(seconds * float64(time.Second))
+func (g *Game) elapsed() time.Duration {
+	// elapsed() returns the time the game has been running, which
+	// doesn't count the time it's been paused.
+	if g.finished {
+		return g.clearTime
+	}
+	now := g.pausedAt
+	if !g.tetris.Paused {
+		now = g.now()
+	}
+	return now.Sub(g.started).Truncate(time.Millisecond)
 }
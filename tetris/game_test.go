@@ -1,6 +1,7 @@
 package tetris_test
 
 import (
+	"reflect"
 	"testing"
 	"tetris/tetris"
 	"time"
@@ -55,3 +56,368 @@ func TestStartStop(t *testing.T) {
 		t.Errorf("Expected game to be over")
 	}
 }
+
+func TestStartOptions(t *testing.T) {
+	game := tetris.NewGame(tetris.WithLevelCap(15), tetris.WithTicker(tetris.NewMockTicker()))
+	go game.Start(tetris.WithSeed(1234), tetris.WithLevelCap(0))
+	<-game.GetUpdate()
+	game.Stop()
+	if r := game.Replay(); r.Seed != 1234 || r.LevelCap != 0 {
+		t.Errorf("wanted the game to have seed 1234 and no level cap, got %d and %d", r.Seed, r.LevelCap)
+	}
+
+	// the options given to Start only apply to the game they started.
+	go game.Start()
+	<-game.GetUpdate()
+	game.Stop()
+	if r := game.Replay(); r.Seed == 1234 || r.LevelCap != 15 {
+		t.Errorf("wanted the next game to have a new seed and a level cap of 15, got %d and %d", r.Seed, r.LevelCap)
+	}
+
+	t.Run("the options of a relayed online match don't outlive it", func(t *testing.T) {
+		game := tetris.NewGame(tetris.WithMode(tetris.Sprint), tetris.WithStartLevel(5), tetris.WithGoal(tetris.VariableGoal), tetris.WithTicker(tetris.NewMockTicker()))
+		go game.Start(tetris.WithMode(tetris.Marathon), tetris.WithStartLevel(1), tetris.WithGoal(tetris.FixedGoal), tetris.WithLevelCap(0))
+		<-game.GetUpdate()
+		game.Stop()
+
+		go game.Start()
+		<-game.GetUpdate()
+		game.Stop()
+		r := game.Replay()
+		if r.Mode != tetris.Sprint || r.StartLevel != 5 || r.Goal != tetris.VariableGoal {
+			t.Errorf("wanted a sprint at level 5 with a variable goal, got a %s at level %d with a %s goal", r.Mode, r.StartLevel, r.Goal)
+		}
+	})
+}
+
+func TestLineClearScore(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.I)
+	tetris.FillRows(te, 0)
+	game, ticker := tetris.NewTestGame(te)
+	go game.Start()
+	<-game.GetUpdate()
+	go game.Action(tetris.DropDown)
+
+	// hard drop from row 19 to row 0 (38 points) plus a single at level 1 (100 points).
+	want := 138
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case u := <-game.GetUpdate():
+			if u.LinesCleared != nil {
+				// the line is scored on lock and removed after the line clear delay.
+				if u.Score != want || u.Stack[0][0] == "" || ticker.Duration() != 320*time.Millisecond {
+					t.Errorf("wanted the scored line to wait for the line clear delay, got score %d", u.Score)
+				}
+				if !reflect.DeepEqual(u.LinesCleared.Rows, []int{0}) || u.LinesCleared.Clear.Lines != 1 || u.Tetromino != nil {
+					t.Errorf("wanted row 0 to be cleared as a single with no tetromino, got %+v", u.LinesCleared)
+				}
+				go ticker.Tick()
+				continue
+			}
+			if u.LinesClear == 0 {
+				continue
+			}
+			if u.Score != want {
+				t.Errorf("wanted score %d, got %d", want, u.Score)
+			}
+			game.Stop()
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for line clear")
+		}
+	}
+}
+
+func TestLockDelay(t *testing.T) {
+	update := func(t *testing.T, g *tetris.Game) *tetris.Tetris {
+		t.Helper()
+		select {
+		case u := <-g.GetUpdate():
+			return u
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for update")
+		}
+		return nil
+	}
+
+	t.Run("grounded tetromino locks after the lock delay", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		te.Tetromino.Y = 2
+		game, ticker := tetris.NewTestGame(te)
+		go game.Start()
+		update(t, game)
+
+		go ticker.Tick()
+		if u := update(t, game); u.Tetromino.Y != 1 {
+			t.Errorf("wanted tetromino to fall to Y 1, got %d", u.Tetromino.Y)
+		}
+		if ticker.Duration() != 500*time.Millisecond {
+			t.Errorf("wanted ticker to be reset to the lock delay, got %v", ticker.Duration())
+		}
+
+		go ticker.Tick()
+		if u := update(t, game); u.Stack[0][3] != tetris.J {
+			t.Errorf("wanted tetromino to be locked in the stack, got %v", u.Stack[0])
+		}
+		game.Stop()
+	})
+
+	t.Run("moving a grounded tetromino resets the lock delay up to 15 times", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		te.Tetromino.Y = 1
+		game, ticker := tetris.NewTestGame(te)
+		go game.Start()
+		update(t, game)
+		want := ticker.Resets()
+
+		for i := range 16 {
+			a := tetris.MoveLeft
+			if i%2 == 0 {
+				a = tetris.MoveRight
+			}
+			go game.Action(a)
+			update(t, game)
+			if i < 15 {
+				want++
+			}
+			if ticker.Resets() != want {
+				t.Errorf("move %d: wanted %d ticker resets, got %d", i+1, want, ticker.Resets())
+			}
+		}
+		game.Stop()
+	})
+
+	t.Run("sliding off a ledge falls at gravity's pace", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		te.Tetromino.Y = 2
+		for x := range 5 {
+			te.Stack[0][x] = tetris.L
+		}
+		game, ticker := tetris.NewTestGame(te)
+		go game.Start()
+		update(t, game)
+
+		// uses up the resets on the ledge, then slides off of it.
+		for i := range 16 {
+			a := tetris.MoveLeft
+			if i%2 == 0 {
+				a = tetris.MoveRight
+			}
+			go game.Action(a)
+			update(t, game)
+		}
+		for range 2 {
+			go game.Action(tetris.MoveRight)
+			update(t, game)
+		}
+		// gravity at level 1 is a row per second.
+		if ticker.Duration() != time.Second {
+			t.Errorf("wanted ticker to be reset to gravity, got %v", ticker.Duration())
+		}
+		game.Stop()
+	})
+
+	t.Run("landing again on the same row without resets left locks", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		te.Tetromino.Y = 2
+		for x := range 5 {
+			te.Stack[0][x] = tetris.L
+		}
+		game, _ := tetris.NewTestGame(te)
+		go game.Start()
+		update(t, game)
+
+		// uses up the resets on the ledge, slides off of it and back on.
+		for i := range 16 {
+			a := tetris.MoveLeft
+			if i%2 == 0 {
+				a = tetris.MoveRight
+			}
+			go game.Action(a)
+			update(t, game)
+		}
+		for _, a := range []tetris.Action{tetris.MoveRight, tetris.MoveRight, tetris.MoveLeft} {
+			go game.Action(a)
+			update(t, game)
+		}
+		if te.Stack[1][4] != tetris.J {
+			t.Errorf("wanted tetromino to be locked on the ledge, got %v", te.Stack[1])
+		}
+		game.Stop()
+	})
+
+	t.Run("moving an airborne tetromino doesn't reset gravity", func(t *testing.T) {
+		te := tetris.NewTestTetris(tetris.J)
+		game, ticker := tetris.NewTestGame(te)
+		go game.Start()
+		update(t, game)
+		want := ticker.Resets()
+
+		go game.Action(tetris.MoveLeft)
+		update(t, game)
+		if ticker.Resets() != want {
+			t.Errorf("wanted %d ticker resets, got %d", want, ticker.Resets())
+		}
+		game.Stop()
+	})
+}
+
+func TestHoldBlockOut(t *testing.T) {
+	tests := []struct {
+		name string
+		held bool
+	}{
+		{name: "the held tetromino swapped back in overlaps the stack", held: true},
+		{name: "the tetromino drawn on the first hold overlaps the stack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := tetris.NewTestTetris(tetris.J)
+			te.Tetromino.Y = 2
+			if tt.held {
+				te.HoldTetromino = tetris.NewTestTetris(tetris.T).Tetromino
+			}
+			for _, y := range []int{18, 19} {
+				for x := 3; x < 7; x++ {
+					te.Stack[y][x] = tetris.L
+				}
+			}
+			game, _ := tetris.NewTestGame(te)
+			go game.Start()
+			<-game.GetUpdate()
+
+			go game.Action(tetris.Hold)
+			if u := <-game.GetUpdate(); !u.GameOver {
+				t.Errorf("wanted the game to be over")
+			}
+			game.Stop()
+		})
+	}
+}
+
+func TestPause(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.J)
+	game, ticker := tetris.NewTestGame(te)
+	go game.Start()
+	u := <-game.GetUpdate()
+	wantX := u.Tetromino.X
+	gravity := ticker.Duration()
+	time.Sleep(20 * time.Millisecond)
+
+	go game.Pause()
+	if u := <-game.GetUpdate(); !u.Paused {
+		t.Errorf("wanted game to be paused")
+	}
+	if !ticker.IsStop() {
+		t.Errorf("wanted ticker to be stopped while paused")
+	}
+	resets := ticker.Resets()
+
+	// actions are ignored while paused.
+	game.Action(tetris.MoveLeft)
+
+	go game.Resume()
+	u = <-game.GetUpdate()
+	if u.Paused {
+		t.Errorf("wanted game to be resumed")
+	}
+	if u.Tetromino.X != wantX {
+		t.Errorf("wanted tetromino to stay on X %d while paused, got %d", wantX, u.Tetromino.X)
+	}
+	if ticker.Resets() != resets+1 {
+		t.Errorf("wanted ticker to be reset on resume")
+	}
+	// the ticker only gets back the time it had left, so pausing doesn't
+	// hold off gravity nor the lock delay.
+	if d := ticker.Duration(); d <= 0 || d > gravity-20*time.Millisecond {
+		t.Errorf("wanted ticker to be resumed with less than %v left, got %v", gravity-20*time.Millisecond, d)
+	}
+
+	// the first tick after resuming sets the ticker back to gravity.
+	wantY := u.Tetromino.Y - 1
+	go ticker.Tick()
+	if u := <-game.GetUpdate(); u.Tetromino.Y != wantY {
+		t.Errorf("wanted tetromino to fall on the tick after resuming")
+	}
+	if d := ticker.Duration(); d != gravity {
+		t.Errorf("wanted ticker to tick every %v after resuming, got %v", gravity, d)
+	}
+	game.Stop()
+}
+
+func TestWithTicker(t *testing.T) {
+	ticker := tetris.NewMockTicker()
+	start := time.Now()
+	game := tetris.NewGame(
+		tetris.WithSeed(1),
+		tetris.WithMode(tetris.Ultra),
+		tetris.WithTicker(ticker),
+		tetris.WithTimeSource(func() time.Time { return start }),
+	)
+	go game.Start()
+	u := <-game.GetUpdate()
+	wantY := u.Tetromino.Y - 1
+
+	go ticker.Tick()
+	u = <-game.GetUpdate()
+	if u.Tetromino.Y != wantY {
+		t.Errorf("wanted tetromino to fall to Y %d on the tick, got %d", wantY, u.Tetromino.Y)
+	}
+	if u.Time != 0 {
+		t.Errorf("wanted the time from the time source, got %v", u.Time)
+	}
+	game.Stop()
+}
+
+func TestActionAfterGameOver(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.J)
+	game, _ := tetris.NewTestGame(te)
+	game.Start()
+	<-game.GetUpdate()
+	game.Stop()
+
+	// actions don't wait for a game that is over.
+	done := make(chan struct{})
+	go func() {
+		game.Action(tetris.MoveLeft)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("wanted the action to be dropped once the game is over")
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	for _, a := range tetris.Actions() {
+		if got, err := tetris.ParseAction(string(a)); err != nil || got != a {
+			t.Errorf("wanted action %q, got %q with error %v", a, got, err)
+		}
+	}
+	if _, err := tetris.ParseAction("teleport"); err == nil {
+		t.Errorf("wanted an error for an unknown action")
+	}
+}
+
+func TestPauseAfterGameOver(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.J)
+	game, _ := tetris.NewTestGame(te)
+	game.Start()
+	<-game.GetUpdate()
+	game.Stop()
+
+	// pausing doesn't wait for a game that is over.
+	done := make(chan struct{})
+	go func() {
+		game.Pause()
+		game.Resume()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("wanted pause and resume to be dropped once the game is over")
+	}
+}
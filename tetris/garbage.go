@@ -0,0 +1,84 @@
+package tetris
+
+// Garbage is the shape of the cells of garbage lines.
+const Garbage Shape = "G"
+
+// attackLines are the garbage lines a clear sends to the opponent.
+// https://tetris.wiki/Garbage#Guideline
+var attackLines = map[TSpin]map[int]int{
+	NoTSpin: {
+		2: 1, // Double
+		3: 2, // Triple
+		4: 4, // Tetris
+	},
+	TSpinMini: {
+		2: 1, // Mini T-Spin Double
+	},
+	TSpinFull: {
+		1: 2, // T-Spin Single
+		2: 4, // T-Spin Double
+		3: 6, // T-Spin Triple
+	},
+}
+
+// comboLines are the garbage lines a combo adds, by combo count.
+var comboLines = []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5}
+
+func (t *Tetris) attack(c Clear) {
+	// attack() sends the garbage lines of a clear. back-to-back
+	// difficult clears send one more line and combos add their own. the
+	// lines cancel the garbage waiting to rise into the stack first, and
+	// only what's left is sent to the opponent.
+	// it must be called after chain().
+	lines := attackLines[c.TSpin][c.Lines]
+	if c.difficult() && t.BackToBack > 0 {
+		lines++
+	}
+	lines += comboLines[min(t.Combo, len(comboLines)-1)]
+	cancelled := min(lines, t.GarbagePending)
+	t.GarbagePending -= cancelled
+	t.GarbageSent += lines - cancelled
+}
+
+func (t *Tetris) receiveGarbage(sent int) int {
+	// receiveGarbage() adds the lines the opponent has sent since the
+	// last time to the pending garbage, and returns how many they are.
+	lines := sent - t.garbageReceived
+	if lines <= 0 {
+		return 0
+	}
+	t.garbageReceived = sent
+	t.GarbagePending += lines
+	return lines
+}
+
+func (t *Tetris) riseGarbage() bool {
+	// riseGarbage() pushes the pending garbage into the stack from the
+	// bottom, as gray lines with a single hole in the same random column.
+	// it returns false if the stack is pushed over the top.
+	if t.GarbagePending == 0 {
+		return true
+	}
+	lines := min(t.GarbagePending, len(t.Stack))
+	t.GarbagePending = 0
+	hole := t.garbageRand.Intn(len(t.Stack[0]))
+	toppedOut := false
+	for _, row := range t.Stack[len(t.Stack)-lines:] {
+		for _, c := range row {
+			if c != "" {
+				toppedOut = true
+			}
+		}
+	}
+	rows := make([][]Shape, lines)
+	for i := range rows {
+		rows[i] = make([]Shape, len(t.Stack[0]))
+		for x := range rows[i] {
+			if x != hole {
+				rows[i][x] = Garbage
+			}
+		}
+	}
+	t.Stack = append(rows, t.Stack[:len(t.Stack)-lines]...)
+	return !toppedOut
+}
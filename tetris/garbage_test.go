@@ -0,0 +1,117 @@
+package tetris
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAttack(t *testing.T) {
+	tests := []struct {
+		name        string
+		clears      []Clear
+		pending     int
+		wantSent    int
+		wantPending int
+	}{
+		{name: "single", clears: []Clear{{Lines: 1}}},
+		{name: "double", clears: []Clear{{Lines: 2}}, wantSent: 1},
+		{name: "tetris", clears: []Clear{{Lines: 4}}, wantSent: 4},
+		{name: "T-Spin double", clears: []Clear{{Lines: 2, TSpin: TSpinFull}}, wantSent: 4},
+		{name: "mini T-Spin single", clears: []Clear{{Lines: 1, TSpin: TSpinMini}}},
+		{name: "back-to-back tetris", clears: []Clear{{Lines: 4}, {Lines: 4}}, wantSent: 4 + 5},
+		{name: "combo", clears: []Clear{{Lines: 1}, {Lines: 1}, {Lines: 1}}, wantSent: 1},
+		{name: "the clear cancels pending garbage", clears: []Clear{{Lines: 4}}, pending: 3, wantSent: 1},
+		{name: "the clear doesn't cancel all pending garbage", clears: []Clear{{Lines: 3}}, pending: 3, wantPending: 1},
+		{name: "a lock without lines sends nothing", clears: []Clear{{}}, pending: 3, wantPending: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := NewTestTetris(J)
+			te.GarbagePending = tt.pending
+			for _, c := range tt.clears {
+				te.chain(c)
+				te.attack(c)
+				te.LastClear = c
+			}
+			if te.GarbageSent != tt.wantSent || te.GarbagePending != tt.wantPending {
+				t.Errorf("wanted %d lines sent and %d pending, got %d sent and %d pending", tt.wantSent, tt.wantPending, te.GarbageSent, te.GarbagePending)
+			}
+		})
+	}
+}
+
+func TestRiseGarbage(t *testing.T) {
+	t.Run("garbage rises from the bottom with a single hole", func(t *testing.T) {
+		te := NewTestTetris(J)
+		te.Stack[0][0] = J
+		te.receiveGarbage(2)
+		if !te.riseGarbage() {
+			t.Fatal("wanted the stack not to top out")
+		}
+		hole := slices.Index(te.Stack[0], "")
+		for y := range 2 {
+			if slices.Index(te.Stack[y], "") != hole || garbageCells(te.Stack[y]) != 9 {
+				t.Errorf("wanted row %d to be garbage with a hole on column %d, got %v", y, hole, te.Stack[y])
+			}
+		}
+		if te.Stack[2][0] != J {
+			t.Errorf("wanted the stack to be pushed up by 2 rows, got %v", te.Stack[2])
+		}
+		if te.GarbagePending != 0 || len(te.Stack) != 20 {
+			t.Errorf("wanted no pending garbage and 20 rows, got %d pending and %d rows", te.GarbagePending, len(te.Stack))
+		}
+	})
+
+	t.Run("garbage already received is not received again", func(t *testing.T) {
+		te := NewTestTetris(J)
+		if l := te.receiveGarbage(2); l != 2 {
+			t.Errorf("wanted 2 lines received, got %d", l)
+		}
+		if l := te.receiveGarbage(2); l != 0 || te.GarbagePending != 2 {
+			t.Errorf("wanted no more lines received, got %d and %d pending", l, te.GarbagePending)
+		}
+	})
+
+	t.Run("garbage pushing the stack over the top tops out", func(t *testing.T) {
+		te := NewTestTetris(J)
+		te.Stack[19][0] = J
+		te.receiveGarbage(1)
+		if te.riseGarbage() {
+			t.Errorf("wanted the stack to top out")
+		}
+	})
+}
+
+func TestGarbage(t *testing.T) {
+	te := NewTestTetris(J)
+	g, _ := NewTestGame(te)
+	g.headless = true
+	g.started = g.now()
+	g.newReplay()
+	var events []Event
+	g.Subscribe(func(e Event) { events = append(events, e) })
+	e := &Engine{game: g}
+
+	e.RemoteGarbage(2)
+	e.Step(DropDown)
+	if s := e.Snapshot(); garbageCells(s.Stack[0]) != 9 || garbageCells(s.Stack[1]) != 9 || s.Stack[2][3] != J {
+		t.Errorf("wanted 2 garbage rows under the locked tetromino, got %v", s.Stack[:3])
+	}
+	if events[0] != (GarbageReceived{Lines: 2}) {
+		t.Errorf("wanted the garbage to be received before the action, got %v", events[0])
+	}
+	r := e.Replay()
+	if r.Events[0].Kind != ReplayGarbage || r.Events[0].Garbage != 2 {
+		t.Errorf("wanted the garbage recorded in the replay, got %+v", r.Events[0])
+	}
+}
+
+func garbageCells(row []Shape) int {
+	var n int
+	for _, c := range row {
+		if c == Garbage {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package tetris
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// frame is the unit of time gravity is measured in.
+	frame = time.Second / 60
+	// maxGravity is 20G, the tetromino falls the whole stack in a frame.
+	maxGravity = 20
+	// maxGravityLevel is the first level that reaches maxGravity.
+	maxGravityLevel = 19
+)
+
+func gravity(level int) float64 {
+	// gravity() returns the speed tetrominoes fall at in G, rows per frame.
+	// Based on https://tetris.wiki/Marathon
+	//
+	// Time = (0.8-((Level-1)*0.007))^(Level-1)
+	//
+	// is the seconds it takes to fall one row. the base of the formula
+	// turns negative past level 115 but gravity is capped to 20G way
+	// before, at level 19, so we never use it beyond that.
+	l := float64(min(max(level, 1), maxGravityLevel) - 1)
+	seconds := math.Pow(0.8-l*0.007, l)
+	return min(1/(seconds*60), maxGravity)
+}
+
+func (g *Game) gravity() (time.Duration, int) {
+	// gravity() returns how often the tetromino falls and how many rows.
+	// below 1G it falls one row every few frames, from 1G on it falls
+	// every frame as many whole rows as G.
+	G := gravity(g.tetris.Level)
+	if G < 1 {
+		return time.Duration(float64(time.Second) / (G * 60)), 1
+	}
+	return frame, int(G)
+}
+
+func (g *Game) fall() {
+	// fall() moves the tetromino down as many rows as gravity dictates.
+	_, rows := g.gravity()
+	for range rows {
+		g.tetris.fall()
+	}
+}
+
+// MaxGravity reports whether tetrominoes fall at 20G at the level of t,
+// in which case they land as soon as they spawn or move.
+func (t *Tetris) MaxGravity() bool {
+	return gravity(t.Level) >= maxGravity
+}
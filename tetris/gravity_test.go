@@ -0,0 +1,62 @@
+package tetris
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGravity(t *testing.T) {
+	t.Run("gravity is defined and never decreases over the whole level range", func(t *testing.T) {
+		prev := 0.0
+		for l := range 300 {
+			G := gravity(l)
+			if math.IsNaN(G) || G <= 0 || G > maxGravity {
+				t.Fatalf("level %d: wanted gravity between 0 and %dG, got %v", l, maxGravity, G)
+			}
+			if G < prev {
+				t.Errorf("level %d: wanted gravity to be at least %v, got %v", l, prev, G)
+			}
+			prev = G
+		}
+	})
+
+	tests := []struct {
+		level    int
+		wantTime time.Duration
+		wantRows int
+	}{
+		{level: 1, wantTime: time.Second, wantRows: 1},
+		{level: 2, wantTime: 793 * time.Millisecond, wantRows: 1},
+		{level: 10, wantTime: 64 * time.Millisecond, wantRows: 1},
+		{level: 15, wantTime: frame, wantRows: 2},
+		{level: 18, wantTime: frame, wantRows: 11},
+		{level: 19, wantTime: frame, wantRows: maxGravity},
+		{level: 25, wantTime: frame, wantRows: maxGravity},
+		{level: 200, wantTime: frame, wantRows: maxGravity},
+	}
+	for _, tt := range tests {
+		te := NewTestTetris(J)
+		te.Level = tt.level
+		g, _ := NewTestGame(te)
+		d, rows := g.gravity()
+		if d.Truncate(time.Millisecond) != tt.wantTime.Truncate(time.Millisecond) || rows != tt.wantRows {
+			t.Errorf("level %d: wanted %d rows every %v, got %d rows every %v", tt.level, tt.wantRows, tt.wantTime, rows, d)
+		}
+	}
+
+	t.Run("at 20G the tetromino lands as soon as it spawns", func(t *testing.T) {
+		te := NewTestTetris(J)
+		te.Level = maxGravityLevel
+		g, ticker := NewTestGame(te)
+		go g.Start()
+		u := <-g.GetUpdate()
+		if u.Tetromino.Y != u.Tetromino.GhostY {
+			t.Errorf("wanted tetromino to land on Y %d, got %d", u.Tetromino.GhostY, u.Tetromino.Y)
+		}
+		if ticker.Duration() != lockDelay {
+			t.Errorf("wanted ticker to be reset to the lock delay, got %v", ticker.Duration())
+		}
+		g.Stop()
+	})
+}
@@ -0,0 +1,81 @@
+package tetris
+
+import "fmt"
+
+// Goal is the system that decides how many lines it takes to advance a level.
+// https://tetris.wiki/Marathon
+type Goal string
+
+const (
+	FixedGoal    Goal = "fixed"    // Every level takes 10 lines.
+	VariableGoal Goal = "variable" // Every level takes 5 times its number, awarded by clear type.
+
+	// MaxStartLevel is the highest level a game can start at.
+	MaxStartLevel = 15
+)
+
+// Goals returns the available leveling goals.
+func Goals() []Goal {
+	return []Goal{FixedGoal, VariableGoal}
+}
+
+// ParseGoal returns the Goal named s.
+func ParseGoal(s string) (Goal, error) {
+	g := Goal(s)
+	if g != FixedGoal && g != VariableGoal {
+		return "", fmt.Errorf("unknown goal %q, available: %v", s, Goals())
+	}
+	return g, nil
+}
+
+func (t *Tetris) setLevel() {
+	if t.goal == VariableGoal {
+		t.setVariableLevel()
+	} else {
+		t.setFixedLevel()
+	}
+	// the level stays at the cap once the last level has been completed,
+	// as the game is over.
+	if t.levelCap > 0 && t.Level > t.levelCap {
+		t.Level, t.capped = t.levelCap, true
+	}
+}
+
+func (t *Tetris) setFixedLevel() {
+	// set the fixed-goal level system
+	// https://tetris.wiki/Marathon
+	//
+	// In the fixed-goal system, each level requires 10 lines to clear.
+	// If the player starts at a later level, the number of lines required is the same
+	// as if starting at level 1. An example is when the player starts at level 5,
+	// the player will have to clear 50 lines to advance to level 6
+	var l int
+	switch {
+	case t.LinesClear < 10:
+		l = 1
+	case t.LinesClear >= 10 && t.LinesClear < 100:
+		l = (t.LinesClear/10)%10 + 1
+	case t.LinesClear >= 100:
+		l = t.LinesClear/10 + 1
+	}
+	if l > t.Level {
+		t.Level = l
+	}
+}
+
+func (t *Tetris) setVariableLevel() {
+	// set the variable-goal level system
+	// https://tetris.wiki/Marathon
+	//
+	// In the variable-goal system, each level requires 5 times its number
+	// of lines: 5 lines at level 1, 10 at level 2 and so on. Clears are
+	// awarded lines according to their type instead of the lines they
+	// clear, a Tetris is worth 8 lines and a T-Spin Double 12, which is
+	// their base score divided by 100, back-to-back bonus included.
+	// Awarded lines beyond the goal carry over to the next level.
+	t.awarded += t.clearPoints(t.LastClear) / 100
+	for t.awarded >= 5*t.Level {
+		t.awarded -= 5 * t.Level
+		t.Level++
+	}
+}
@@ -0,0 +1,75 @@
+package tetris
+
+import "time"
+
+// Mode is the set of rules that decide how a game ends.
+type Mode string
+
+const (
+	Marathon Mode = "marathon" // https://tetris.wiki/Marathon
+	Sprint   Mode = "sprint"   // https://tetris.wiki/40_lines
+	Ultra    Mode = "ultra"    // https://tetris.wiki/Ultra
+
+	sprintLines = 40
+	ultraTime   = 2 * time.Minute
+)
+
+type gameMode interface {
+	// won reports whether the player reached the goal of the mode.
+	won(t *Tetris) bool
+	// timed reports whether the elapsed time is part of the mode, so
+	// the game keeps sending updates for the clock to be rendered.
+	timed() bool
+	// limit is how long the game lasts, zero if it has no time limit.
+	limit() time.Duration
+}
+
+var modes = map[Mode]gameMode{
+	Marathon: marathon{},
+	Sprint:   sprint{lines: sprintLines},
+	Ultra:    ultra{time: ultraTime},
+}
+
+// marathon goes on until the stack tops out, or is won by completing
+// the last level if there's a level cap.
+type marathon struct{}
+
+func (marathon) won(t *Tetris) bool   { return t.capped }
+func (marathon) timed() bool          { return false }
+func (marathon) limit() time.Duration { return 0 }
+
+// sprint is won by clearing a number of lines, as fast as possible.
+type sprint struct {
+	lines int
+}
+
+func (s sprint) won(t *Tetris) bool { return t.LinesClear >= s.lines }
+func (sprint) timed() bool          { return true }
+func (sprint) limit() time.Duration { return 0 }
+
+// ultra is a score attack that ends when the time is up. Lasting until
+// then is the goal, so the game is won unless the stack tops out.
+type ultra struct {
+	time time.Duration
+}
+
+func (ultra) won(*Tetris) bool       { return false }
+func (ultra) timed() bool            { return true }
+func (u ultra) limit() time.Duration { return u.time }
+
+// TimeLeft returns the time left before the game ends, zero if the mode
+// has no time limit.
+func (t *Tetris) TimeLeft() time.Duration {
+	l := t.mode().limit()
+	if l == 0 {
+		return 0
+	}
+	return max(l-t.Time, 0)
+}
+
+func (t *Tetris) mode() gameMode {
+	if m, ok := modes[t.Mode]; ok {
+		return m
+	}
+	return modes[Marathon]
+}
@@ -0,0 +1,153 @@
+package tetris
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      Mode
+		wantWon   bool
+		wantClock bool
+		wantTime  time.Duration
+	}{
+		{name: "marathon goes on after 40 lines", mode: Marathon, wantTime: 83776 * time.Millisecond},
+		// the clock stops when the lines are cleared, before the line clear delay.
+		{name: "sprint is won at 40 lines", mode: Sprint, wantWon: true, wantClock: true, wantTime: 83456 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := NewTestTetris(I)
+			te.Mode = tt.mode
+			te.LinesClear = 39
+			fillRows(te, 0)
+			g, ticker := NewTestGame(te)
+			clock := g.clock.(*MockTicker)
+			start := time.Now()
+			now := start
+			g.now = func() time.Time { return now }
+			go g.Start()
+			<-g.GetUpdate()
+			if clock.IsReset() != tt.wantClock {
+				t.Errorf("wanted clock running %t, got %t", tt.wantClock, clock.IsReset())
+			}
+			now = start.Add(83456 * time.Millisecond)
+			go g.Action(DropDown)
+
+			timeout := time.After(time.Second)
+			for {
+				select {
+				case u := <-g.GetUpdate():
+					if u.LinesCleared != nil {
+						// the lines are removed after the line clear delay.
+						now = now.Add(lineClearDelay)
+						go ticker.Tick()
+					}
+					if u.LinesClear < 40 {
+						continue
+					}
+					if u.Won != tt.wantWon || u.GameOver != tt.wantWon {
+						t.Errorf("wanted won and game over %t, got won %t and game over %t", tt.wantWon, u.Won, u.GameOver)
+					}
+					if u.Time != tt.wantTime {
+						t.Errorf("wanted time %v, got %v", tt.wantTime, u.Time)
+					}
+					g.Stop()
+					return
+				case <-timeout:
+					t.Fatal("timed out waiting for line clear")
+				}
+			}
+		})
+	}
+}
+
+func TestUltra(t *testing.T) {
+	te := NewTestTetris(J)
+	te.Mode = Ultra
+	g, _ := NewTestGame(te)
+	limit := g.limit.(*MockTicker)
+	go g.Start()
+	if u := <-g.GetUpdate(); u.TimeLeft() == 0 {
+		t.Errorf("wanted time left at the start of the game")
+	}
+	if limit.Duration() != 2*time.Minute {
+		t.Errorf("wanted time limit of 2m, got %v", limit.Duration())
+	}
+
+	go limit.Tick()
+	select {
+	case u := <-g.GetUpdate():
+		if !u.Won || !u.GameOver {
+			t.Errorf("wanted game to be won and over when time is up, got won %t and game over %t", u.Won, u.GameOver)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for time limit")
+	}
+}
+
+func TestLevelCap(t *testing.T) {
+	te := NewTestTetris(I)
+	te.Level, te.LinesClear, te.levelCap = 15, 149, 15
+	fillRows(te, 0)
+	g, ticker := NewTestGame(te)
+	go g.Start()
+	<-g.GetUpdate()
+	go g.Action(DropDown)
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case u := <-g.GetUpdate():
+			if u.LinesCleared != nil {
+				go ticker.Tick()
+			}
+			if u.LinesClear < 150 {
+				continue
+			}
+			if !u.Won || !u.GameOver {
+				t.Errorf("wanted game to be won and over after the last level, got won %t and game over %t", u.Won, u.GameOver)
+			}
+			if u.Level != 15 {
+				t.Errorf("wanted level to stay at the cap, got %d", u.Level)
+			}
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for line clear")
+		}
+	}
+}
+
+func TestPausedTime(t *testing.T) {
+	te := NewTestTetris(J)
+	te.Mode = Ultra
+	g, _ := NewTestGame(te)
+	limit := g.limit.(*MockTicker)
+	now := time.Now()
+	// the game starts, spawns a tetromino, sets its ticker and sends the
+	// first update at 0s, it's paused at 10s and resumed at 70s.
+	times := []time.Duration{0, 0, 0, 0, 10 * time.Second, 70 * time.Second}
+	g.now = func() time.Time {
+		d := times[0]
+		if len(times) > 1 {
+			times = times[1:]
+		}
+		return now.Add(d)
+	}
+	go g.Start()
+	<-g.GetUpdate()
+	go g.Pause()
+	if u := <-g.GetUpdate(); u.Time != 10*time.Second {
+		t.Errorf("wanted time to be 10s when paused, got %v", u.Time)
+	}
+	go g.Resume()
+	if u := <-g.GetUpdate(); u.Time != 10*time.Second {
+		t.Errorf("wanted time paused not to count, got %v", u.Time)
+	}
+	if limit.Duration() != ultraTime-10*time.Second {
+		t.Errorf("wanted time limit to be reset to the time left, got %v", limit.Duration())
+	}
+	g.Stop()
+}
@@ -0,0 +1,100 @@
+package tetris
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultPreviews = 3
+	maxPreviews     = 6
+)
+
+// Option configures a Game.
+type Option func(*options)
+
+type options struct {
+	seed       int64
+	randomizer RandomizerKind
+	previews   int
+	mode       Mode
+	startLevel int
+	goal       Goal
+	levelCap   int
+	ticker     Ticker
+	now        func() time.Time
+}
+
+// WithSeed sets the seed of the randomizer so the sequence of tetrominoes
+// can be reproduced. A zero seed picks a random one for every new game.
+func WithSeed(seed int64) Option {
+	return func(o *options) { o.seed = seed }
+}
+
+// WithRandomizer sets the randomizer that deals the tetrominoes. Defaults to Bag7.
+func WithRandomizer(k RandomizerKind) Option {
+	return func(o *options) { o.randomizer = k }
+}
+
+// WithPreviews sets how many tetrominoes of the Next queue can be seen,
+// from 1 to 6. Defaults to 3.
+func WithPreviews(n int) Option {
+	return func(o *options) { o.previews = min(max(n, 1), maxPreviews) }
+}
+
+// WithMode sets the game mode. Defaults to Marathon.
+func WithMode(m Mode) Option {
+	return func(o *options) { o.mode = m }
+}
+
+// WithStartLevel sets the level the game starts at, from 1 to 15. Defaults to 1.
+func WithStartLevel(l int) Option {
+	return func(o *options) { o.startLevel = min(max(l, 1), MaxStartLevel) }
+}
+
+// WithGoal sets the leveling system. Defaults to FixedGoal.
+func WithGoal(g Goal) Option {
+	return func(o *options) { o.goal = g }
+}
+
+// WithLevelCap ends the game as won once the level is completed,
+// https://tetris.wiki/Marathon ends after level 15. A cap below the start
+// level is raised to it. Defaults to 0, no cap.
+func WithLevelCap(l int) Option {
+	return func(o *options) { o.levelCap = max(l, 0) }
+}
+
+// WithTicker sets the ticker that drives gravity and the lock delay, so
+// the game only moves forward on its ticks and the actions it gets, like
+// when playing back a Replay. Games with a custom ticker don't refresh
+// the clock nor end by themselves when the time limit of the mode is up.
+func WithTicker(t Ticker) Option {
+	return func(o *options) { o.ticker = t }
+}
+
+// WithTimeSource sets the function the game gets the current time from.
+// Defaults to time.Now.
+func WithTimeSource(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		randomizer: Bag7,
+		previews:   defaultPreviews,
+		mode:       Marathon,
+		startLevel: 1,
+		goal:       FixedGoal,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) newSeed() int64 {
+	if o.seed != 0 {
+		return o.seed
+	}
+	return rand.Int63() //nolint: gosec
+}
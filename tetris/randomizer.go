@@ -0,0 +1,159 @@
+package tetris
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Randomizer decides the sequence in which tetrominoes are dealt.
+// https://tetris.wiki/Random_Generator
+type Randomizer interface {
+	Next() Shape
+}
+
+// RandomizerKind names one of the available Randomizer implementations.
+type RandomizerKind string
+
+const (
+	Bag7   RandomizerKind = "7bag"   // Guideline Random Generator. https://tetris.wiki/Random_Generator
+	Bag14  RandomizerKind = "14bag"  // Random Generator with two copies of every tetromino per bag.
+	Random RandomizerKind = "random" // Every tetromino has the same chance on every draw.
+	NES    RandomizerKind = "nes"    // Rerolls once on a repeated tetromino. https://tetris.wiki/Tetris_(NES)
+	TGM    RandomizerKind = "tgm"    // History of 4 with 6 rolls. https://tetris.wiki/TGM_randomizer
+)
+
+var randomizers = map[RandomizerKind]func(*rand.Rand) Randomizer{
+	Bag7:   func(r *rand.Rand) Randomizer { return newBag(r, 1) },
+	Bag14:  func(r *rand.Rand) Randomizer { return newBag(r, 2) },
+	Random: func(r *rand.Rand) Randomizer { return &pureRandom{rand: r} },
+	NES:    func(r *rand.Rand) Randomizer { return &nes{rand: r} },
+	TGM:    func(r *rand.Rand) Randomizer { return newTGM(r) },
+}
+
+// RandomizerKinds returns the names of all the available randomizers.
+func RandomizerKinds() []RandomizerKind {
+	return []RandomizerKind{Bag7, Bag14, Random, NES, TGM}
+}
+
+// ParseRandomizer returns the RandomizerKind named s.
+func ParseRandomizer(s string) (RandomizerKind, error) {
+	k := RandomizerKind(s)
+	if _, ok := randomizers[k]; !ok {
+		return "", fmt.Errorf("unknown randomizer %q, available: %v", s, RandomizerKinds())
+	}
+	return k, nil
+}
+
+func newRandomizer(k RandomizerKind, seed int64) Randomizer {
+	r := rand.New(rand.NewSource(seed)) //nolint: gosec
+	if f, ok := randomizers[k]; ok {
+		return f(r)
+	}
+	return randomizers[Bag7](r)
+}
+
+// firstShapes are the only tetrominoes that can be dealt first
+// by the randomizers that avoid starting with an overhang.
+var firstShapes = []Shape{I, T, J, L}
+
+type bag struct {
+	firstDraw bool
+	copies    int
+	bag       []Shape
+	rand      *rand.Rand
+}
+
+func newBag(r *rand.Rand, copies int) *bag {
+	b := &bag{
+		firstDraw: true,
+		copies:    copies,
+		rand:      r,
+	}
+	b.fill()
+	return b
+}
+
+func (b *bag) fill() {
+	for range b.copies {
+		b.bag = append(b.bag, shapes...)
+	}
+}
+
+func (b *bag) Next() Shape {
+	// https://tetris.wiki/Random_Generator
+	// first piece is always I, J, L, or T
+	// new bag is generated after last piece is drawn
+	if len(b.bag) == 0 {
+		b.fill()
+	}
+	i := b.rand.Intn(len(b.bag))
+	s := b.bag[i]
+	if b.firstDraw && !slices.Contains(firstShapes, s) {
+		return b.Next()
+	}
+	b.firstDraw = false
+	b.bag = append(b.bag[:i], b.bag[i+1:]...)
+	return s
+}
+
+type pureRandom struct {
+	rand *rand.Rand
+}
+
+func (p *pureRandom) Next() Shape {
+	return shapes[p.rand.Intn(len(shapes))]
+}
+
+type nes struct {
+	rand *rand.Rand
+	prev Shape
+}
+
+func (n *nes) Next() Shape {
+	// https://tetris.wiki/Tetris_(NES)#Randomizer
+	// the NES rolls an 8 sided die where the 8th side is a reroll. it also
+	// rerolls if the tetromino is the same as the previous one. the reroll
+	// is a regular 7 sided die and its result is final.
+	i := n.rand.Intn(len(shapes) + 1)
+	if i == len(shapes) || shapes[i] == n.prev {
+		i = n.rand.Intn(len(shapes))
+	}
+	n.prev = shapes[i]
+	return n.prev
+}
+
+type tgm struct {
+	rand      *rand.Rand
+	firstDraw bool
+	history   []Shape
+}
+
+func newTGM(r *rand.Rand) *tgm {
+	return &tgm{
+		rand:      r,
+		firstDraw: true,
+		history:   []Shape{Z, S, S, Z},
+	}
+}
+
+func (g *tgm) Next() Shape {
+	// https://tetris.wiki/TGM_randomizer
+	// the last 4 dealt tetrominoes are kept in a history. the randomizer
+	// rolls up to 6 times to find one that's not in the history, keeping
+	// the last roll otherwise. the first piece is never S, Z or O.
+	var s Shape
+	if g.firstDraw {
+		s = firstShapes[g.rand.Intn(len(firstShapes))]
+		g.firstDraw = false
+	} else {
+		for range 6 {
+			s = shapes[g.rand.Intn(len(shapes))]
+			if !slices.Contains(g.history, s) {
+				break
+			}
+		}
+	}
+	g.history = append(g.history[1:], s)
+	return s
+}
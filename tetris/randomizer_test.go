@@ -0,0 +1,195 @@
+package tetris
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestRandomBag(t *testing.T) {
+	t.Run("bag should contain 7 elements. after drawing it should contain one less", func(t *testing.T) {
+		t.Parallel()
+		bag := newBag(rand.New(rand.NewSource(rand.Int63())), 1)
+		if len(bag.bag) != 7 {
+			t.Errorf("wanted bag to have 7 pieces, got %d", len(bag.bag))
+		}
+		bag.Next()
+		if len(bag.bag) != 6 {
+			t.Errorf("wanted bag to have 6 pieces, got %d", len(bag.bag))
+		}
+	})
+
+	t.Run("first draw should always be I, J, L or T", func(t *testing.T) {
+		t.Parallel()
+		for range 10 {
+			go func() {
+				bag := newBag(rand.New(rand.NewSource(rand.Int63())), 1)
+				shape := bag.Next()
+				if shape == O || shape == Z || shape == S {
+					t.Errorf("wanted I, J, L, or T, got %v", shape)
+				}
+			}()
+		}
+	})
+
+	t.Run("after drawing 7 tetrominos the bag should empty. next draw whould replenish it", func(t *testing.T) {
+		t.Parallel()
+		bag := newBag(rand.New(rand.NewSource(rand.Int63())), 1)
+		for range 7 {
+			bag.Next()
+		}
+		if len(bag.bag) != 0 {
+			t.Errorf("wanted bag to be empty, got %d pieces", len(bag.bag))
+		}
+		bag.Next()
+		if len(bag.bag) != 6 {
+			t.Errorf("wanted bag to have 6 pieces, got %d", len(bag.bag))
+		}
+	})
+}
+
+func TestSeededBag(t *testing.T) {
+	for _, k := range RandomizerKinds() {
+		t.Run(fmt.Sprintf("%s randomizers with the same seed draw the same sequence", k), func(t *testing.T) {
+			a, b := newRandomizer(k, 42), newRandomizer(k, 42)
+			for i := range 50 {
+				if sa, sb := a.Next(), b.Next(); sa != sb {
+					t.Fatalf("draw %d: wanted same shapes, got %v and %v", i, sa, sb)
+				}
+			}
+		})
+	}
+
+	t.Run("tetris with a seed option uses and exposes it", func(t *testing.T) {
+		a, b := newTetris(newOptions(WithSeed(7))), newTetris(newOptions(WithSeed(7)))
+		if a.Seed != 7 || a.read().Seed != 7 {
+			t.Errorf("wanted seed 7, got %d", a.Seed)
+		}
+		if a.Tetromino.Shape != b.Tetromino.Shape || a.Next[0].Shape != b.Next[0].Shape {
+			t.Errorf("wanted same tetrominoes, got %v, %v and %v, %v", a.Tetromino.Shape, a.Next[0].Shape, b.Tetromino.Shape, b.Next[0].Shape)
+		}
+	})
+
+	t.Run("tetris without a seed option gets a random seed", func(t *testing.T) {
+		if s := newTetris(newOptions()).Seed; s == 0 {
+			t.Errorf("wanted a random seed, got %d", s)
+		}
+	})
+}
+
+func TestRandomizerDistribution(t *testing.T) {
+	// every randomizer deals every tetromino with roughly the same frequency
+	// in the long run. on top of that each one has its own properties.
+	draws := 7 * 2000
+	tests := []struct {
+		kind  RandomizerKind
+		check func(t *testing.T, seq []Shape)
+	}{
+		{
+			kind: Bag7,
+			check: func(t *testing.T, seq []Shape) {
+				for i := 0; i < len(seq); i += 7 {
+					if !isPermutation(seq[i:i+7], 1) {
+						t.Fatalf("wanted bag %d to have one of each tetromino, got %v", i/7, seq[i:i+7])
+					}
+				}
+			},
+		},
+		{
+			kind: Bag14,
+			check: func(t *testing.T, seq []Shape) {
+				for i := 0; i < len(seq); i += 14 {
+					if !isPermutation(seq[i:i+14], 2) {
+						t.Fatalf("wanted bag %d to have two of each tetromino, got %v", i/14, seq[i:i+14])
+					}
+				}
+			},
+		},
+		{
+			kind: Random,
+			check: func(t *testing.T, seq []Shape) {
+				// a pure random randomizer repeats 1/7 of the time.
+				if r := repeats(seq, 1); r < 0.12 || r > 0.165 {
+					t.Errorf("wanted repeats to be around 14%%, got %.1f%%", r*100)
+				}
+			},
+		},
+		{
+			kind: NES,
+			check: func(t *testing.T, seq []Shape) {
+				// the reroll brings repeats down to 1/28.
+				if r := repeats(seq, 1); r < 0.02 || r > 0.05 {
+					t.Errorf("wanted repeats to be around 3.6%%, got %.1f%%", r*100)
+				}
+			},
+		},
+		{
+			kind: TGM,
+			check: func(t *testing.T, seq []Shape) {
+				if slices.Contains([]Shape{S, Z, O}, seq[0]) {
+					t.Errorf("wanted first tetromino not to be S, Z or O, got %v", seq[0])
+				}
+				// a tetromino from the last 4 only comes back when 6 rolls fail.
+				if r := repeats(seq, 4); r > 0.05 {
+					t.Errorf("wanted repeats within 4 draws to be rare, got %.1f%%", r*100)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			t.Parallel()
+			r := newRandomizer(tt.kind, 1)
+			seq := make([]Shape, draws)
+			count := make(map[Shape]int)
+			for i := range seq {
+				seq[i] = r.Next()
+				count[seq[i]]++
+			}
+			want := draws / len(shapes)
+			for _, s := range shapes {
+				if c := count[s]; c < want*85/100 || c > want*115/100 {
+					t.Errorf("wanted around %d %v tetrominoes, got %d", want, s, c)
+				}
+			}
+			tt.check(t, seq)
+		})
+	}
+}
+
+func TestParseRandomizer(t *testing.T) {
+	for _, k := range RandomizerKinds() {
+		if got, err := ParseRandomizer(string(k)); err != nil || got != k {
+			t.Errorf("wanted %v, got %v with error %v", k, got, err)
+		}
+	}
+	if _, err := ParseRandomizer("nope"); err == nil {
+		t.Error("wanted error for unknown randomizer")
+	}
+}
+
+func isPermutation(seq []Shape, copies int) bool {
+	count := make(map[Shape]int)
+	for _, s := range seq {
+		count[s]++
+	}
+	for _, s := range shapes {
+		if count[s] != copies {
+			return false
+		}
+	}
+	return true
+}
+
+func repeats(seq []Shape, history int) float64 {
+	// repeats returns the ratio of tetrominoes that were already dealt
+	// within the previous history draws.
+	var r int
+	for i := history; i < len(seq); i++ {
+		if slices.Contains(seq[i-history:i], seq[i]) {
+			r++
+		}
+	}
+	return float64(r) / float64(len(seq)-history)
+}
@@ -0,0 +1,120 @@
+package tetris
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// ReplayEventKind is the kind of a ReplayEvent.
+type ReplayEventKind string
+
+const (
+	ReplayAction  ReplayEventKind = "action"  // The player made an Action.
+	ReplayTick    ReplayEventKind = "tick"    // The gravity or lock delay ticker ticked.
+	ReplaySpawn   ReplayEventKind = "spawn"   // A tetromino spawned.
+	ReplayTimeUp  ReplayEventKind = "timeup"  // The time limit of the mode was reached.
+	ReplayGarbage ReplayEventKind = "garbage" // Garbage was received from the opponent.
+)
+
+// Replay is the record of a game: the options it was played with and
+// every event that moved it forward, so it can be played back.
+type Replay struct {
+	Seed       int64          `json:"seed"`
+	Randomizer RandomizerKind `json:"randomizer"`
+	Previews   int            `json:"previews"`
+	Mode       Mode           `json:"mode"`
+	StartLevel int            `json:"startLevel"`
+	Goal       Goal           `json:"goal"`
+	LevelCap   int            `json:"levelCap,omitempty"`
+	Events     []ReplayEvent  `json:"events"`
+}
+
+// ReplayEvent is an event of a game, timestamped with the time elapsed
+// since the game started.
+type ReplayEvent struct {
+	Time time.Duration   `json:"time"`
+	Kind ReplayEventKind `json:"kind"`
+	// Action is the action of ReplayAction events.
+	Action Action `json:"action,omitempty"`
+	// Shape is the tetromino that spawned in ReplaySpawn events.
+	Shape Shape `json:"shape,omitempty"`
+	// Garbage are the garbage lines the opponent had sent so far in
+	// ReplayGarbage events.
+	Garbage int32 `json:"garbage,omitempty"`
+}
+
+// Options returns the options to play back the replay with.
+func (r *Replay) Options() []Option {
+	return []Option{
+		WithSeed(r.Seed),
+		WithRandomizer(r.Randomizer),
+		WithPreviews(r.Previews),
+		WithMode(r.Mode),
+		WithStartLevel(r.StartLevel),
+		WithGoal(r.Goal),
+		WithLevelCap(r.LevelCap),
+	}
+}
+
+// Write writes the replay as JSON.
+func (r *Replay) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(r); err != nil {
+		return fmt.Errorf("unable to encode replay: %w", err)
+	}
+	return nil
+}
+
+// ReadReplay reads a replay written with Replay.Write.
+func ReadReplay(r io.Reader) (*Replay, error) {
+	var rp Replay
+	if err := json.NewDecoder(r).Decode(&rp); err != nil {
+		return nil, fmt.Errorf("unable to decode replay: %w", err)
+	}
+	return &rp, nil
+}
+
+// Replay returns the replay of the last game started, nil if none was.
+// It's complete once the game is over.
+func (g *Game) Replay() *Replay {
+	if g.replay == nil {
+		return nil
+	}
+	r := *g.replay
+	r.Events = slices.Clone(g.replay.Events)
+	return &r
+}
+
+func (g *Game) newReplay() {
+	g.replay = &Replay{
+		Seed:       g.tetris.Seed,
+		Randomizer: g.gameOpts.randomizer,
+		Previews:   g.gameOpts.previews,
+		Mode:       g.tetris.Mode,
+		StartLevel: g.tetris.Level,
+		Goal:       g.gameOpts.goal,
+		LevelCap:   g.gameOpts.levelCap,
+	}
+}
+
+func (g *Game) recordEvent(e Event) {
+	// recordEvent() records the events the replay needs to check it's
+	// played back in sync with the game.
+	if s, ok := e.(PieceSpawned); ok {
+		g.record(ReplayEvent{Kind: ReplaySpawn, Shape: s.Shape})
+	}
+}
+
+func (g *Game) record(e ReplayEvent) {
+	// record() adds the event to the replay. nothing is recorded once
+	// the game is over as the replay might already be read.
+	if g.tetris.GameOver {
+		return
+	}
+	e.Time = g.elapsed()
+	g.replay.Events = append(g.replay.Events, e)
+}
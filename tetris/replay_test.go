@@ -0,0 +1,67 @@
+package tetris_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"tetris/tetris"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	te := tetris.NewTestTetris(tetris.J)
+	te.Seed = 1234
+	game, ticker := tetris.NewTestGame(te)
+	if game.Replay() != nil {
+		t.Errorf("wanted no replay before the game starts")
+	}
+	go game.Start()
+	<-game.GetUpdate()
+	go game.Action(tetris.MoveLeft)
+	<-game.GetUpdate()
+	go ticker.Tick()
+	<-game.GetUpdate()
+	go game.Action(tetris.DropDown)
+	<-game.GetUpdate()
+	game.Stop()
+
+	r := game.Replay()
+	if r.Seed != 1234 {
+		t.Errorf("wanted replay seed 1234, got %d", r.Seed)
+	}
+	want := []tetris.ReplayEvent{
+		{Kind: tetris.ReplaySpawn, Shape: tetris.J},
+		{Kind: tetris.ReplayAction, Action: tetris.MoveLeft},
+		{Kind: tetris.ReplayTick},
+		{Kind: tetris.ReplayAction, Action: tetris.DropDown},
+		{Kind: tetris.ReplaySpawn, Shape: tetris.J},
+	}
+	if len(r.Events) != len(want) {
+		t.Fatalf("wanted %d events, got %d: %v", len(want), len(r.Events), r.Events)
+	}
+	var last time.Duration
+	for i, e := range r.Events {
+		if e.Time < last {
+			t.Errorf("event %d: wanted events in chronological order, got %v after %v", i, e.Time, last)
+		}
+		last = e.Time
+		e.Time = 0
+		if e != want[i] {
+			t.Errorf("event %d: wanted %+v, got %+v", i, want[i], e)
+		}
+	}
+
+	t.Run("replays can be written and read back", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := r.Write(&b); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tetris.ReadReplay(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, r) {
+			t.Errorf("wanted replay %+v, got %+v", r, got)
+		}
+	})
+}
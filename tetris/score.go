@@ -0,0 +1,124 @@
+package tetris
+
+import "slices"
+
+// TSpin is the kind of T-Spin a lock has been classified as.
+// https://tetris.wiki/T-Spin
+type TSpin string
+
+const (
+	NoTSpin   TSpin = ""
+	TSpinMini TSpin = "mini"
+	TSpinFull TSpin = "full"
+)
+
+// Clear describes what happened the last time a Tetromino locked.
+type Clear struct {
+	Lines int
+	TSpin TSpin
+}
+
+func (c Clear) String() string {
+	lines := []string{"", "Single", "Double", "Triple", "Tetris"}[c.Lines]
+	switch c.TSpin {
+	case TSpinMini:
+		return join("Mini T-Spin", lines)
+	case TSpinFull:
+		return join("T-Spin", lines)
+	}
+	return lines
+}
+
+// difficult reports whether the clear keeps the back-to-back chain going.
+func (c Clear) difficult() bool {
+	return c.Lines == 4 || (c.TSpin != NoTSpin && c.Lines > 0)
+}
+
+func join(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + " " + b
+}
+
+// LinesCleared are the lines a lock just completed: the rows of the
+// stack they are in, from the bottom, and the kind of clear.
+type LinesCleared struct {
+	Rows  []int
+	Clear Clear
+}
+
+func (l *LinesCleared) copy() *LinesCleared {
+	if l == nil {
+		return nil
+	}
+	return &LinesCleared{Rows: slices.Clone(l.Rows), Clear: l.Clear}
+}
+
+func (t *Tetris) chain(c Clear) {
+	// chain() updates the combo and back-to-back counters with the clear
+	// of the lock that just happened. it must be called before LastClear
+	// is replaced, as it holds the result of the previous lock.
+	//
+	// every consecutive lock that clears lines increases the combo,
+	// and any lock that doesn't clear lines resets it.
+	switch {
+	case c.Lines == 0:
+		t.Combo = 0
+	case t.LastClear.Lines > 0:
+		t.Combo++
+	}
+
+	// tetrises and T-Spin line clears are difficult clears. consecutive
+	// difficult clears are back-to-back, and only an easy line clear
+	// breaks the chain.
+	if c.Lines == 0 {
+		return
+	}
+	if !c.difficult() {
+		t.BackToBack = 0
+		t.difficult = false
+		return
+	}
+	if t.difficult {
+		t.BackToBack++
+	}
+	t.difficult = true
+}
+
+func (t *Tetris) score(c Clear) {
+	// https://tetris.wiki/Scoring#Recent_guideline_compatible_games
+	// clears are worth their base points multiplied by the level they
+	// happened at, and combos add 50 points per combo count.
+	t.Score += (t.clearPoints(c) + 50*t.Combo) * t.Level
+}
+
+func (t *Tetris) clearPoints(c Clear) int {
+	// clearPoints() returns the base points of a clear. back-to-back
+	// difficult clears are worth 1.5 times their base points.
+	points := clearPoints[c.TSpin][c.Lines]
+	if c.difficult() && t.BackToBack > 0 {
+		points = points * 3 / 2
+	}
+	return points
+}
+
+var clearPoints = map[TSpin]map[int]int{
+	NoTSpin: {
+		1: 100, // Single
+		2: 300, // Double
+		3: 500, // Triple
+		4: 800, // Tetris
+	},
+	TSpinMini: {
+		0: 100, // Mini T-Spin
+		1: 200, // Mini T-Spin Single
+		2: 400, // Mini T-Spin Double
+	},
+	TSpinFull: {
+		0: 400,  // T-Spin
+		1: 800,  // T-Spin Single
+		2: 1200, // T-Spin Double
+		3: 1600, // T-Spin Triple
+	},
+}
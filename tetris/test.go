@@ -1,6 +1,7 @@
 package tetris
 
 import (
+	"math/rand"
 	"sync"
 	"time"
 )
@@ -9,6 +10,8 @@ import (
 type MockTicker struct {
 	ch          chan time.Time
 	stop, reset bool
+	resets      int
+	duration    time.Duration
 	mu          sync.Mutex
 }
 
@@ -16,10 +19,22 @@ func newMockTicker() *MockTicker          { return &MockTicker{ch: make(chan tim
 func (m *MockTicker) C() <-chan time.Time { return m.ch }
 func (m *MockTicker) Stop()               { m.stop = true }
 func (m *MockTicker) Tick()               { m.ch <- time.Now() }
-func (m *MockTicker) Reset(time.Duration) {
+func (m *MockTicker) Reset(d time.Duration) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.reset = true
+	m.resets++
+	m.duration = d
+}
+func (m *MockTicker) Resets() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.resets
+}
+func (m *MockTicker) Duration() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.duration
 }
 func (m *MockTicker) IsReset() bool {
 	m.mu.Lock()
@@ -35,22 +50,34 @@ func (m *MockTicker) IsStop() bool {
 // NewTestGame creates a game with a specific TestTetris and returns a game and a manual ticker.
 func NewTestGame(t *Tetris) (*Game, *MockTicker) {
 	ticker := newMockTicker()
-	return &Game{
+	o := newOptions()
+	g := &Game{
 		updateCh: make(chan *Tetris),
 		actionCh: make(chan Action),
+		pauseCh:  make(chan bool),
 		tetris:   t,
 		ticker:   ticker,
-	}, ticker
+		opts:     o,
+		gameOpts: o,
+		clock:    newMockTicker(),
+		limit:    newMockTicker(),
+		now:      time.Now,
+	}
+	g.Subscribe(g.recordEvent)
+	return g, ticker
 }
 
 // NewTestTetris creates a new Tetris struct with a test tetromino.
 func NewTestTetris(shape Shape) *Tetris {
 	t := &Tetris{
-		Tetromino:    shapeMap[shape](),
-		NexTetromino: shapeMap[shape](),
-		Stack:        emptyStack(),
-		Level:        1,
-		bag:          newBag(),
+		Tetromino:   shapeMap[shape](),
+		Next:        []*Tetromino{shapeMap[shape]()},
+		Stack:       emptyStack(),
+		Level:       1,
+		randomizer:  newRandomizer(Bag7, 0),
+		previews:    1,
+		Mode:        Marathon,
+		garbageRand: rand.New(rand.NewSource(0)), //nolint: gosec
 	}
 	t.Tetromino.GhostY = t.Tetromino.Y + t.dropDownDelta()
 	return t
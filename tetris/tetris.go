@@ -14,7 +14,7 @@ package tetris
 
 import (
 	"math/rand"
-	"slices"
+	"time"
 )
 
 type Tetris struct {
@@ -24,59 +24,162 @@ type Tetris struct {
 	// An empty string is an empty cell. Otherwise it has the color it will be rendered with.
 	Stack [][]Shape
 
-	Tetromino    *Tetromino
-	NexTetromino *Tetromino
+	Tetromino     *Tetromino
+	Next          []*Tetromino // https://tetris.wiki/Next
+	HoldTetromino *Tetromino   // https://tetris.wiki/Hold_piece
 
 	Level      int
 	LinesClear int
+	Score      int
+	// Pieces is the number of tetrominoes locked so far.
+	Pieces    int
+	LastClear Clear
+	// LinesCleared are the lines completed by the last lock while they
+	// wait for the line clear delay to be removed, nil otherwise.
+	LinesCleared *LinesCleared
+	BackToBack   int // https://tetris.wiki/Back-to-Back
+	Combo        int // https://tetris.wiki/Combo
 
+	// GarbageSent are the garbage lines sent to the opponent so far and
+	// GarbagePending the ones received that are waiting to rise into
+	// the stack. https://tetris.wiki/Garbage
+	GarbageSent    int
+	GarbagePending int
+
+	// Mode is the game mode. Time is the time elapsed since the game
+	// started and Won is true when the game ended by reaching the goal
+	// of the mode instead of topping out.
+	Mode     Mode
+	Time     time.Duration
+	Won      bool
+	Paused   bool
 	GameOver bool
 
-	bag *bag
+	// Seed is the seed of the randomizer. Games with the same
+	// seed get the same sequence of tetrominoes.
+	Seed int64
+
+	randomizer Randomizer
+	// previews is the length of the Next queue.
+	previews int
+	// held is true when hold has already been used since the last lock.
+	held bool
+	// rotated is true when the last successful movement of the current
+	// tetromino was a rotation, and kick is the wall kick test it used.
+	rotated bool
+	kick    int
+	// difficult is true when the last line clear was a difficult one.
+	difficult bool
+	// goal is the leveling system and awarded the lines awarded towards
+	// the next level in the variable-goal system.
+	goal    Goal
+	awarded int
+	// levelCap is the last level of the game, zero if there's none, and
+	// capped is true once it has been completed.
+	levelCap int
+	capped   bool
+	// garbageReceived are the garbage lines received so far, and
+	// garbageRand picks the hole of the garbage lines.
+	garbageReceived int
+	garbageRand     *rand.Rand
 }
 
-func newTetris() *Tetris {
+func newTetris(o *options) *Tetris {
+	seed := o.newSeed()
 	t := &Tetris{
-		Stack: emptyStack(),
-		Level: 1,
-		bag:   newBag(),
+		Stack:      emptyStack(),
+		Level:      o.startLevel,
+		Seed:       seed,
+		randomizer: newRandomizer(o.randomizer, seed),
+		previews:   o.previews,
+		Mode:       o.mode,
+		goal:       o.goal,
+		levelCap:   o.levelCap,
+		// the garbage of games with the same seed has the same holes.
+		garbageRand: rand.New(rand.NewSource(seed)), //nolint: gosec
+	}
+	if t.levelCap > 0 {
+		// a cap below the start level would be completed on the first lock.
+		t.levelCap = max(t.levelCap, t.Level)
 	}
 	t.setTetromino()
 	return t
 }
 
-func (t *Tetris) action(a Action) {
+func (t *Tetris) action(a Action) bool {
+	// action() returns whether the action succeeded in moving, rotating
+	// or holding the tetromino.
 	if t.Tetromino == nil {
 		// between toStack() and next round's setTetromino() Tetromino is nil.
 		// we return here to avoid user commands to cause panic.
-		return
+		return false
 	}
+	var ok bool
 	switch a {
 	case MoveLeft:
 		if !t.isCollision(-1, 0, t.Tetromino) {
 			t.Tetromino.X--
+			ok = true
 		}
 	case MoveRight:
 		if !t.isCollision(1, 0, t.Tetromino) {
 			t.Tetromino.X++
+			ok = true
 		}
 	case MoveDown:
+		// soft drop awards 1 point per cell.
 		if !t.isCollision(0, -1, t.Tetromino) {
 			t.Tetromino.Y--
+			t.Score++
+			ok = true
 		}
 	case DropDown:
-		t.Tetromino.Y += t.dropDownDelta()
+		// hard drop awards 2 points per cell.
+		delta := t.dropDownDelta()
+		if delta < 0 {
+			t.Tetromino.Y += delta
+			t.Score -= delta * 2
+			ok = true
+		}
+	case Hold:
+		return t.hold()
 	default:
-		t.rotate(a)
+		return t.rotate(a)
+	}
+	if ok {
+		t.rotated = false
 	}
 	t.Tetromino.GhostY = t.Tetromino.Y + t.dropDownDelta()
+	return ok
+}
+
+// Try returns where the tetromino tm would be after the action a, and
+// whether it succeeded in moving or rotating it in the stack. Neither t
+// nor tm change, which lets bots look for the placements of the current
+// tetromino. Hold and DropDown are not tried and always fail.
+func (t *Tetris) Try(tm *Tetromino, a Action) (*Tetromino, bool) {
+	if a == Hold || a == DropDown {
+		return tm, false
+	}
+	try := &Tetris{Stack: t.Stack, Tetromino: tm.copy()}
+	ok := try.action(a)
+	return try.Tetromino, ok
 }
 
-func (t *Tetris) rotate(a Action) {
+func (t *Tetris) fall() {
+	// fall() moves the tetromino one row down by gravity.
+	// unlike a soft drop, it doesn't award any points.
+	if t.Tetromino != nil && !t.isCollision(0, -1, t.Tetromino) {
+		t.Tetromino.Y--
+		t.rotated = false
+	}
+}
+
+func (t *Tetris) rotate(a Action) bool {
 	// https://tetris.wiki/Super_Rotation_System
 	if t.Tetromino.Shape == O {
 		// the O shape doesn't rotate.
-		return
+		return false
 	}
 
 	// we create a test Tetromino with the current XY coordinates
@@ -130,33 +233,97 @@ func (t *Tetris) rotate(a Action) {
 		rGroup = "I"
 	}
 
-	for _, v := range wallKickMap[rGroup][rCase] {
+	for i, v := range wallKickMap[rGroup][rCase] {
 		if !t.isCollision(v[0], v[1], test) {
 			t.Tetromino.Grid = test.Grid
 			t.Tetromino.X += v[0]
 			t.Tetromino.Y += v[1]
+			t.rotated = true
+			t.kick = i
 			switch a {
 			case RotateRight:
 				t.Tetromino.rState = t.Tetromino.rState.Next()
 			case RotateLeft:
 				t.Tetromino.rState = t.Tetromino.rState.Prev()
 			}
-			return
+			t.Tetromino.GhostY = t.Tetromino.Y + t.dropDownDelta()
+			return true
 		}
 	}
+	return false
+}
+
+func (t *Tetris) hold() bool {
+	// https://tetris.wiki/Hold_piece
+	// hold can only be used once until the current tetromino locks.
+	// the held tetromino always comes back in its spawn orientation.
+	if t.held {
+		return false
+	}
+	t.held = true
+	t.rotated = false
+	current := shapeMap[t.Tetromino.Shape]()
+	if t.HoldTetromino == nil {
+		t.HoldTetromino = current
+		t.setTetromino()
+		return true
+	}
+	t.Tetromino = shapeMap[t.HoldTetromino.Shape]()
+	t.Tetromino.GhostY = t.Tetromino.Y + t.dropDownDelta()
+	t.HoldTetromino = current
+	return true
+}
+
+func (t *Tetris) tSpin() TSpin {
+	// https://tetris.wiki/T-Spin
+	// a T tetromino whose last movement was a rotation is a T-Spin when
+	// 3 of the 4 corners around its center are occupied (walls count).
+	// it's a full T-Spin when both corners its nub points to are occupied,
+	// or when the rotation used the last wall kick test. otherwise it's a mini.
+	if t.Tetromino == nil || t.Tetromino.Shape != T || !t.rotated {
+		return NoTSpin
+	}
+
+	occupied := func(corner []int) int {
+		y := t.Tetromino.Y - corner[0]
+		x := t.Tetromino.X + corner[1]
+		if y < 0 || y > 19 || x < 0 || x > 9 || t.Stack[y][x] != "" {
+			return 1
+		}
+		return 0
+	}
+
+	var front, back int
+	for _, c := range tCorners[t.Tetromino.rState.Value.(string)] {
+		front += occupied(c)
+	}
+	for _, c := range tCorners[t.Tetromino.rState.Prev().Prev().Value.(string)] {
+		back += occupied(c)
+	}
+
+	switch {
+	case front+back < 3:
+		return NoTSpin
+	case front == 2 || t.kick == 4:
+		return TSpinFull
+	default:
+		return TSpinMini
+	}
 }
 
 func (t *Tetris) setTetromino() {
-	if t.Tetromino == nil && t.NexTetromino == nil {
-		t.Tetromino = t.bag.draw()
-		t.NexTetromino = t.bag.draw()
-	} else {
-		t.Tetromino = t.NexTetromino
-		t.NexTetromino = t.bag.draw()
+	// the queue holds the tetromino we're about to take plus the previews.
+	for len(t.Next) <= t.previews {
+		t.Next = append(t.Next, t.draw())
 	}
+	t.Tetromino, t.Next = t.Next[0], t.Next[1:]
 	t.Tetromino.GhostY = t.Tetromino.Y + t.dropDownDelta()
 }
 
+func (t *Tetris) draw() *Tetromino {
+	return shapeMap[t.randomizer.Next()]()
+}
+
 func (t *Tetris) isCollision(deltaX, deltaY int, tetromino *Tetromino) bool {
 	// isCollision() will receive the desired future X and Y tetromino's position
 	// and calculate if there is a collision or if it's out of bounds from the stack
@@ -191,36 +358,29 @@ func (t *Tetris) toStack() {
 		}
 	}
 	t.Tetromino = nil
-}
-
-func (t *Tetris) setLevel() {
-	// set the fixed-goal level system
-	// https://tetris.wiki/Marathon
-	//
-	// In the fixed-goal system, each level requires 10 lines to clear.
-	// If the player starts at a later level, the number of lines required is the same
-	// as if starting at level 1. An example is when the player starts at level 5,
-	// the player will have to clear 50 lines to advance to level 6
-	var l int
-	switch {
-	case t.LinesClear < 10:
-		l = 1
-	case t.LinesClear >= 10 && t.LinesClear < 100:
-		l = (t.LinesClear/10)%10 + 1
-	case t.LinesClear >= 100:
-		l = t.LinesClear/10 + 1
-	}
-	if l > t.Level {
-		t.Level = l
-	}
+	t.Pieces++
+	t.held = false
+	t.rotated = false
 }
 
 func (t *Tetris) isGameOver() bool {
 	// we consider game over when next tetromino spawn position would have a collision on the stack.
-	t.GameOver = t.isCollision(0, 0, t.NexTetromino)
+	t.GameOver = t.isCollision(0, 0, t.Next[0])
 	return t.GameOver
 }
 
+func (t *Tetris) isBlockedOut() bool {
+	// the game is over when the tetromino coming in from the hold slot or
+	// the queue would spawn overlapping the stack, as with any other spawn.
+	t.GameOver = t.isCollision(0, 0, t.Tetromino)
+	return t.GameOver
+}
+
+func (t *Tetris) isGrounded() bool {
+	// isGrounded() reports whether the tetromino is resting on the stack or the floor.
+	return t.Tetromino != nil && t.isCollision(0, -1, t.Tetromino)
+}
+
 func (t *Tetris) dropDownDelta() int {
 	var delta int
 	for !t.isCollision(0, delta, t.Tetromino) {
@@ -239,52 +399,32 @@ func (t *Tetris) read() *Tetris {
 			copy(stack[i], t.Stack[i])
 		}
 	}
-	return &Tetris{
-		Stack:        stack,
-		Tetromino:    t.Tetromino.copy(),
-		NexTetromino: t.NexTetromino.copy(),
-		Level:        t.Level,
-		LinesClear:   t.LinesClear,
-		GameOver:     t.GameOver,
+	next := make([]*Tetromino, len(t.Next))
+	for i := range t.Next {
+		next[i] = t.Next[i].copy()
 	}
-}
-
-type bag struct {
-	firstDraw bool
-	bag       []*Tetromino
-}
-
-func newBag() *bag {
-	return &bag{
-		firstDraw: true,
-		bag:       newTetrominoList(),
-	}
-}
-
-func (b *bag) draw() *Tetromino {
-	// https://tetris.wiki/Random_Generator
-	// first piece is always I, J, L, or T
-	// new bag is generated after last piece is drawn
-	if len(b.bag) == 0 {
-		b.bag = newTetrominoList()
-	}
-	firstDrawList := []Shape{I, T, J, L}
-	i := rand.Intn(len(b.bag)) //nolint: gosec
-	t := b.bag[i]
-	if b.firstDraw && !slices.Contains(firstDrawList, t.Shape) {
-		return b.draw()
-	}
-	b.firstDraw = false
-	b.bag = append(b.bag[:i], b.bag[i+1:]...)
-	return t
-}
-
-func newTetrominoList() []*Tetromino {
-	var b []*Tetromino
-	for _, t := range shapeMap {
-		b = append(b, t())
+	return &Tetris{
+		Stack:          stack,
+		Tetromino:      t.Tetromino.copy(),
+		Next:           next,
+		HoldTetromino:  t.HoldTetromino.copy(),
+		Level:          t.Level,
+		LinesClear:     t.LinesClear,
+		Score:          t.Score,
+		Pieces:         t.Pieces,
+		LastClear:      t.LastClear,
+		LinesCleared:   t.LinesCleared.copy(),
+		BackToBack:     t.BackToBack,
+		Combo:          t.Combo,
+		GarbageSent:    t.GarbageSent,
+		GarbagePending: t.GarbagePending,
+		Mode:           t.Mode,
+		Time:           t.Time,
+		Won:            t.Won,
+		Paused:         t.Paused,
+		GameOver:       t.GameOver,
+		Seed:           t.Seed,
 	}
-	return b
 }
 
 func emptyStack() [][]Shape {
@@ -295,6 +435,15 @@ func emptyStack() [][]Shape {
 	return e
 }
 
+// tCorners are the grid coordinates (y, x) of the two corners a T tetromino's
+// nub points to for each rotation state.
+var tCorners = map[string][][]int{
+	rState0: {{0, 0}, {0, 2}},
+	rStateR: {{0, 2}, {2, 2}},
+	rState2: {{2, 0}, {2, 2}},
+	rStateL: {{0, 0}, {2, 0}},
+}
+
 var wallKickMap = map[string]map[string][][]int{
 	"all": {
 		"0>R": [][]int{{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
@@ -486,48 +486,6 @@ func TestRead(t *testing.T) {
 	}
 }
 
-func TestRandomBag(t *testing.T) {
-	t.Run("bag should contain 7 elements. after drawing it should contain one less", func(t *testing.T) {
-		t.Parallel()
-		bag := newBag()
-		if len(bag.bag) != 7 {
-			t.Errorf("wanted bag to have 7 pieces, got %d", len(bag.bag))
-		}
-		bag.draw()
-		if len(bag.bag) != 6 {
-			t.Errorf("wanted bag to have 6 pieces, got %d", len(bag.bag))
-		}
-	})
-
-	t.Run("first draw should always be I, J, L or T", func(t *testing.T) {
-		t.Parallel()
-		for range 10 {
-			go func() {
-				bag := newBag()
-				tetromino := bag.draw()
-				if tetromino.Shape == O || tetromino.Shape == Z || tetromino.Shape == S {
-					t.Errorf("wanted I, J, L, or T, got %v", tetromino.Shape)
-				}
-			}()
-		}
-	})
-
-	t.Run("after drawing 7 tetrominos the bag should empty. next draw whould replenish it", func(t *testing.T) {
-		t.Parallel()
-		bag := newBag()
-		for range 7 {
-			bag.draw()
-		}
-		if len(bag.bag) != 0 {
-			t.Errorf("wanted bag to be empty, got %d pieces", len(bag.bag))
-		}
-		bag.draw()
-		if len(bag.bag) != 6 {
-			t.Errorf("wanted bag to have 6 pieces, got %d", len(bag.bag))
-		}
-	})
-}
-
 func TestSetLevel(t *testing.T) {
 	tests := []struct {
 		lines, wantLevel int
@@ -544,7 +502,7 @@ func TestSetLevel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("for %d lines should have level %d", tt.lines, tt.wantLevel), func(t *testing.T) {
-			tetris := newTetris()
+			tetris := newTetris(newOptions())
 			tetris.LinesClear = tt.lines
 			tetris.setLevel()
 			if tetris.Level != tt.wantLevel {
@@ -554,7 +512,7 @@ func TestSetLevel(t *testing.T) {
 	}
 
 	t.Run("set level is not overriden until lines > level", func(t *testing.T) {
-		tetris := newTetris()
+		tetris := newTetris(newOptions())
 		tetris.Level = 5
 		tetris.LinesClear = 1
 		tetris.setLevel()
@@ -567,22 +525,151 @@ func TestSetLevel(t *testing.T) {
 			t.Errorf("wanted level 6, got %d", tetris.Level)
 		}
 	})
+
+	t.Run("level is clamped to the cap once completed", func(t *testing.T) {
+		tetris := newTetris(newOptions(WithLevelCap(3)))
+		tetris.LinesClear = 29
+		tetris.setLevel()
+		if tetris.Level != 3 || tetris.mode().won(tetris) {
+			t.Errorf("wanted level 3 not won yet, got level %d", tetris.Level)
+		}
+		tetris.LinesClear = 30
+		tetris.setLevel()
+		if tetris.Level != 3 || !tetris.mode().won(tetris) {
+			t.Errorf("wanted level 3 won, got level %d", tetris.Level)
+		}
+	})
+
+	t.Run("won has no side effects", func(t *testing.T) {
+		tetris := newTetris(newOptions(WithLevelCap(3)))
+		tetris.LinesClear = 29
+		tetris.setLevel()
+		for range 2 {
+			if tetris.mode().won(tetris) || tetris.Level != 3 || tetris.capped {
+				t.Errorf("wanted level 3 not capped nor won, got level %d capped %t", tetris.Level, tetris.capped)
+			}
+		}
+		tetris.LinesClear = 30
+		tetris.setLevel()
+		for range 2 {
+			if !tetris.mode().won(tetris) || tetris.Level != 3 || !tetris.capped {
+				t.Errorf("wanted level 3 capped and won, got level %d capped %t", tetris.Level, tetris.capped)
+			}
+		}
+	})
+
+	t.Run("level cap below the start level", func(t *testing.T) {
+		tetris := newTetris(newOptions(WithStartLevel(10), WithLevelCap(5)))
+		tetris.LinesClear = 1
+		tetris.setLevel()
+		if tetris.Level != 10 || tetris.mode().won(tetris) {
+			t.Errorf("wanted level 10 not won, got level %d", tetris.Level)
+		}
+		tetris.LinesClear = 100
+		tetris.setLevel()
+		if tetris.Level != 10 || !tetris.mode().won(tetris) {
+			t.Errorf("wanted level 10 won, got level %d", tetris.Level)
+		}
+	})
+
+	t.Run("start level option", func(t *testing.T) {
+		for l, want := range map[int]int{0: 1, 5: 5, 20: 15} {
+			if tetris := newTetris(newOptions(WithStartLevel(l))); tetris.Level != want {
+				t.Errorf("start level %d: wanted level %d, got %d", l, want, tetris.Level)
+			}
+		}
+	})
+}
+
+func TestSetVariableLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		level      int
+		clears     []Clear
+		backToBack bool
+		wantLevel  int
+	}{
+		{
+			name:      "5 singles advance level 1",
+			level:     1,
+			clears:    []Clear{{Lines: 1}, {Lines: 1}, {Lines: 1}, {Lines: 1}, {Lines: 1}},
+			wantLevel: 2,
+		},
+		{
+			name:      "a tetris is awarded 8 lines and carries 3 over",
+			level:     1,
+			clears:    []Clear{{Lines: 4}, {Lines: 2}},
+			wantLevel: 2,
+		},
+		{
+			name:      "a T-Spin double is awarded 12 lines",
+			level:     2,
+			clears:    []Clear{{Lines: 2, TSpin: TSpinFull}},
+			wantLevel: 3,
+		},
+		{
+			name:       "back-to-back tetris is awarded 12 lines",
+			level:      2,
+			clears:     []Clear{{Lines: 4}},
+			backToBack: true,
+			wantLevel:  3,
+		},
+		{
+			name:      "a tetris is not enough at level 2",
+			level:     2,
+			clears:    []Clear{{Lines: 4}},
+			wantLevel: 2,
+		},
+		{
+			name:      "a mini T-Spin without lines is awarded 1 line",
+			level:     1,
+			clears:    []Clear{{TSpin: TSpinMini}, {Lines: 2}, {Lines: 1}},
+			wantLevel: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tetris := newTetris(newOptions(WithGoal(VariableGoal), WithStartLevel(tt.level)))
+			if tt.backToBack {
+				tetris.BackToBack = 1
+			}
+			for _, c := range tt.clears {
+				tetris.LinesClear += c.Lines
+				tetris.LastClear = c
+				tetris.setLevel()
+			}
+			if tetris.Level != tt.wantLevel {
+				t.Errorf("wanted level %d, got %d", tt.wantLevel, tetris.Level)
+			}
+		})
+	}
+}
+
+func TestParseGoal(t *testing.T) {
+	for _, g := range Goals() {
+		if got, err := ParseGoal(string(g)); err != nil || got != g {
+			t.Errorf("wanted goal %q, got %q with error %v", g, got, err)
+		}
+	}
+	if _, err := ParseGoal("nope"); err == nil {
+		t.Errorf("wanted error parsing an unknown goal")
+	}
 }
 
 func TestSetTetromino(t *testing.T) {
 	t.Run("first time it populates current and next tetromino", func(t *testing.T) {
-		tetris := newTetris()
+		tetris := newTetris(newOptions())
 		tetris.setTetromino()
-		if tetris.Tetromino == nil || tetris.NexTetromino == nil {
-			t.Errorf("want Tetromino and NextTetromino to not be nil, got: %v, %v", tetris.Tetromino, tetris.NexTetromino)
+		if tetris.Tetromino == nil || len(tetris.Next) == 0 {
+			t.Errorf("want Tetromino and Next to not be empty, got: %v, %v", tetris.Tetromino, tetris.Next)
 		}
 	})
 	t.Run("after tetromino has been transferred to the stack, moves next tetromino to current", func(t *testing.T) {
-		tetris := newTetris()
+		tetris := newTetris(newOptions())
 		tetris.setTetromino()
 		tetris.action(MoveDown)
 		tetris.toStack()
-		wantShape := tetris.NexTetromino.Shape
+		wantShape := tetris.Next[0].Shape
 		tetris.setTetromino()
 		if tetris.Tetromino.Shape != wantShape {
 			t.Errorf("wanted current tetromino to have shape %v, got %v", wantShape, tetris.Tetromino.Shape)
@@ -590,6 +677,44 @@ func TestSetTetromino(t *testing.T) {
 	})
 }
 
+func TestNextQueue(t *testing.T) {
+	tests := []struct {
+		previews, want int
+	}{
+		{0, 1},
+		{1, 1},
+		{3, 3},
+		{6, 6},
+		{10, 6},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d previews show %d tetrominoes", tt.previews, tt.want), func(t *testing.T) {
+			tetris := newTetris(newOptions(WithPreviews(tt.previews)))
+			if len(tetris.Next) != tt.want {
+				t.Fatalf("wanted %d tetrominoes in the queue, got %d", tt.want, len(tetris.Next))
+			}
+			var want []Shape
+			for _, n := range tetris.Next[1:] {
+				want = append(want, n.Shape)
+			}
+			wantCurrent := tetris.Next[0].Shape
+			tetris.toStack()
+			tetris.setTetromino()
+			if tetris.Tetromino.Shape != wantCurrent {
+				t.Errorf("wanted current tetromino to be %v, got %v", wantCurrent, tetris.Tetromino.Shape)
+			}
+			if len(tetris.Next) != tt.want {
+				t.Fatalf("wanted %d tetrominoes in the queue, got %d", tt.want, len(tetris.Next))
+			}
+			for i, w := range want {
+				if tetris.Next[i].Shape != w {
+					t.Errorf("wanted queue position %d to be %v, got %v", i, w, tetris.Next[i].Shape)
+				}
+			}
+		})
+	}
+}
+
 func TestIsGameOver(t *testing.T) {
 	tetris := NewTestTetris(J)
 	if tetris.isGameOver() {
@@ -606,3 +731,305 @@ func TestIsGameOver(t *testing.T) {
 		t.Error("expected GameOver to be true")
 	}
 }
+
+func TestHold(t *testing.T) {
+	t.Run("first hold stores the tetromino and draws the next one", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.Next = []*Tetromino{newT()}
+		tetris.action(Hold)
+		if tetris.HoldTetromino == nil || tetris.HoldTetromino.Shape != J {
+			t.Fatalf("wanted hold tetromino to be J, got %v", tetris.HoldTetromino)
+		}
+		if tetris.Tetromino.Shape != T {
+			t.Errorf("wanted current tetromino to be T, got %v", tetris.Tetromino.Shape)
+		}
+	})
+
+	t.Run("hold swaps the current tetromino with the held one in spawn orientation", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.HoldTetromino = newT()
+		tetris.action(RotateRight)
+		tetris.action(MoveDown)
+		tetris.action(Hold)
+		if tetris.Tetromino.Shape != T {
+			t.Errorf("wanted current tetromino to be T, got %v", tetris.Tetromino.Shape)
+		}
+		want := newJ()
+		if !reflect.DeepEqual(tetris.HoldTetromino.Grid, want.Grid) {
+			t.Errorf("wanted held grid %v, got %v", want.Grid, tetris.HoldTetromino.Grid)
+		}
+		if tetris.HoldTetromino.X != want.X || tetris.HoldTetromino.Y != want.Y {
+			t.Errorf("wanted held tetromino at spawn %d,%d, got %d,%d", want.X, want.Y, tetris.HoldTetromino.X, tetris.HoldTetromino.Y)
+		}
+		if tetris.HoldTetromino.rState.Value != rState0 {
+			t.Errorf("wanted held tetromino rotation state to be %s, got %v", rState0, tetris.HoldTetromino.rState.Value)
+		}
+	})
+
+	t.Run("hold can only be used once until the tetromino locks", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.HoldTetromino = newT()
+		tetris.action(Hold)
+		tetris.action(Hold)
+		if tetris.Tetromino.Shape != T {
+			t.Errorf("wanted second hold to be ignored, got current tetromino %v", tetris.Tetromino.Shape)
+		}
+		tetris.Next = []*Tetromino{newO()}
+		tetris.toStack()
+		tetris.setTetromino()
+		tetris.action(Hold)
+		if tetris.HoldTetromino.Shape != O {
+			t.Errorf("wanted hold to be available after lock, got held tetromino %v", tetris.HoldTetromino.Shape)
+		}
+	})
+}
+
+func TestScore(t *testing.T) {
+	t.Run("soft drop awards 1 point per cell", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.action(MoveDown)
+		tetris.action(MoveDown)
+		if tetris.Score != 2 {
+			t.Errorf("wanted score 2, got %d", tetris.Score)
+		}
+	})
+
+	t.Run("blocked soft drop doesn't award points", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.Stack[17][3] = J
+		tetris.action(MoveDown)
+		if tetris.Score != 0 {
+			t.Errorf("wanted score 0, got %d", tetris.Score)
+		}
+	})
+
+	t.Run("hard drop awards 2 points per cell", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.action(DropDown)
+		if tetris.Score != 36 {
+			t.Errorf("wanted score 36, got %d", tetris.Score)
+		}
+	})
+
+	t.Run("gravity doesn't award points", func(t *testing.T) {
+		tetris := NewTestTetris(J)
+		tetris.fall()
+		if tetris.Score != 0 {
+			t.Errorf("wanted score 0, got %d", tetris.Score)
+		}
+		if tetris.Tetromino.Y != 18 {
+			t.Errorf("wanted tetromino's Y to be 18, got %d", tetris.Tetromino.Y)
+		}
+	})
+
+	tests := []struct {
+		lines, level, want int
+	}{
+		{0, 1, 0},
+		{1, 1, 100},
+		{2, 1, 300},
+		{3, 1, 500},
+		{4, 1, 800},
+		{1, 5, 500},
+		{4, 10, 8000},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d lines at level %d award %d points", tt.lines, tt.level, tt.want), func(t *testing.T) {
+			tetris := NewTestTetris(J)
+			tetris.Level = tt.level
+			tetris.score(Clear{Lines: tt.lines})
+			if tetris.Score != tt.want {
+				t.Errorf("wanted score %d, got %d", tt.want, tetris.Score)
+			}
+		})
+	}
+}
+
+func TestTSpin(t *testing.T) {
+	// the T tetromino is rotated twice (pointing down) and placed so its
+	// grid corners are at rows 2 (back) and 0 (front), columns 3 and 5.
+	//
+	// .	0 1 2 3 4 5 6 7 8 9
+	// 2	. . . b . b . . . .
+	// 1	. . . O O O . . . .
+	// 0	. . . f O f . . . .
+	tests := []struct {
+		name       string
+		blockStack [][]int
+		kick       int
+		noRotation bool
+		want       TSpin
+	}{
+		{
+			name:       "two corners is not a T-Spin",
+			blockStack: [][]int{{0, 3}, {0, 5}},
+			want:       NoTSpin,
+		},
+		{
+			name:       "both front and one back corners is a T-Spin",
+			blockStack: [][]int{{0, 3}, {0, 5}, {2, 3}},
+			want:       TSpinFull,
+		},
+		{
+			name:       "one front and both back corners is a mini T-Spin",
+			blockStack: [][]int{{0, 3}, {2, 3}, {2, 5}},
+			want:       TSpinMini,
+		},
+		{
+			name:       "one front and both back corners with the last kick test is a T-Spin",
+			blockStack: [][]int{{0, 3}, {2, 3}, {2, 5}},
+			kick:       4,
+			want:       TSpinFull,
+		},
+		{
+			name:       "last movement not being a rotation is not a T-Spin",
+			blockStack: [][]int{{0, 3}, {0, 5}, {2, 3}},
+			noRotation: true,
+			want:       NoTSpin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tetris := NewTestTetris(T)
+			tetris.Tetromino.Y = 10
+			tetris.rotate(RotateRight)
+			tetris.rotate(RotateRight)
+			tetris.Tetromino.Y = 2
+			for _, v := range tt.blockStack {
+				tetris.Stack[v[0]][v[1]] = J
+			}
+			tetris.kick = tt.kick
+			tetris.rotated = !tt.noRotation
+			if got := tetris.tSpin(); got != tt.want {
+				t.Errorf("wanted %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("walls count as occupied corners", func(t *testing.T) {
+		// T pointing left against the right wall, the wall covers both back
+		// corners and a block covers one of the front ones.
+		tetris := NewTestTetris(T)
+		tetris.Tetromino.Y = 10
+		tetris.rotate(RotateLeft)
+		tetris.Tetromino.X = 8
+		tetris.Tetromino.Y = 2
+		tetris.Stack[0][8] = J
+		if got := tetris.tSpin(); got != TSpinMini {
+			t.Errorf("wanted %q, got %q", TSpinMini, got)
+		}
+	})
+
+	t.Run("rotation records the wall kick test and moving resets it", func(t *testing.T) {
+		tetris := NewTestTetris(I)
+		tetris.Tetromino.Y = 10
+		tetris.Stack[10][5] = J
+		tetris.rotate(RotateRight)
+		if !tetris.rotated || tetris.kick != 1 {
+			t.Errorf("wanted rotation with kick 1, got rotated %t with kick %d", tetris.rotated, tetris.kick)
+		}
+		tetris.action(MoveDown)
+		if tetris.rotated {
+			t.Errorf("wanted rotated to be false after moving")
+		}
+	})
+}
+
+func TestClearScore(t *testing.T) {
+	tests := []struct {
+		clear Clear
+		name  string
+		want  int
+	}{
+		{Clear{TSpin: TSpinMini}, "Mini T-Spin", 100},
+		{Clear{Lines: 1, TSpin: TSpinMini}, "Mini T-Spin Single", 200},
+		{Clear{Lines: 2, TSpin: TSpinMini}, "Mini T-Spin Double", 400},
+		{Clear{TSpin: TSpinFull}, "T-Spin", 400},
+		{Clear{Lines: 1, TSpin: TSpinFull}, "T-Spin Single", 800},
+		{Clear{Lines: 2, TSpin: TSpinFull}, "T-Spin Double", 1200},
+		{Clear{Lines: 3, TSpin: TSpinFull}, "T-Spin Triple", 1600},
+		{Clear{Lines: 4}, "Tetris", 800},
+		{Clear{}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.clear.String() != tt.name {
+				t.Errorf("wanted name %q, got %q", tt.name, tt.clear.String())
+			}
+			tetris := NewTestTetris(T)
+			tetris.Level = 2
+			tetris.score(tt.clear)
+			if tetris.Score != tt.want*2 {
+				t.Errorf("wanted score %d, got %d", tt.want*2, tetris.Score)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	tetrisClear := Clear{Lines: 4}
+	single := Clear{Lines: 1}
+	tSpinDouble := Clear{Lines: 2, TSpin: TSpinFull}
+	tSpin := Clear{TSpin: TSpinFull}
+	tests := []struct {
+		name           string
+		clears         []Clear
+		wantBackToBack int
+		wantCombo      int
+		wantScore      int
+	}{
+		{
+			name:      "single clear has no chain",
+			clears:    []Clear{single},
+			wantScore: 100,
+		},
+		{
+			name:      "consecutive line clears build a combo",
+			clears:    []Clear{single, single, single},
+			wantCombo: 2,
+			wantScore: 100 + (100 + 50) + (100 + 100),
+		},
+		{
+			name:      "lock without lines breaks the combo",
+			clears:    []Clear{single, single, {}},
+			wantScore: 100 + (100 + 50),
+		},
+		{
+			name:           "consecutive difficult clears are back-to-back",
+			clears:         []Clear{tetrisClear, {}, tSpinDouble, {}, tetrisClear},
+			wantBackToBack: 2,
+			wantScore:      800 + 1800 + 1200,
+		},
+		{
+			name:           "T-Spin without lines doesn't break back-to-back",
+			clears:         []Clear{tetrisClear, tSpin, tetrisClear},
+			wantBackToBack: 1,
+			wantScore:      800 + 400 + 1200,
+		},
+		{
+			name:      "easy line clear breaks back-to-back",
+			clears:    []Clear{tetrisClear, {}, single, {}, tetrisClear},
+			wantScore: 800 + 100 + 800,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tetris := NewTestTetris(T)
+			for _, c := range tt.clears {
+				tetris.chain(c)
+				tetris.score(c)
+				tetris.LastClear = c
+			}
+			if tetris.BackToBack != tt.wantBackToBack {
+				t.Errorf("wanted back-to-back %d, got %d", tt.wantBackToBack, tetris.BackToBack)
+			}
+			if tetris.Combo != tt.wantCombo {
+				t.Errorf("wanted combo %d, got %d", tt.wantCombo, tetris.Combo)
+			}
+			if tetris.Score != tt.wantScore {
+				t.Errorf("wanted score %d, got %d", tt.wantScore, tetris.Score)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package tetris
 
-import "container/ring"
+import (
+	"container/ring"
+	"slices"
+)
 
 type Shape string
 
@@ -20,6 +23,8 @@ const (
 	rState2 = "2" // two steps in any direction from spawn
 )
 
+var shapes = []Shape{I, J, L, O, S, Z, T}
+
 var shapeMap = map[Shape]func() *Tetromino{
 	I: newI,
 	J: newJ,
@@ -54,7 +59,19 @@ func (t *Tetromino) copy() *Tetromino {
 		X:      t.X,
 		Y:      t.Y,
 		GhostY: t.GhostY,
+		rState: t.rState,
+	}
+}
+
+func (t *Tetromino) bottom() int {
+	// bottom() returns the row of the lowest cell of the tetromino, as
+	// its Y is the top of its grid.
+	for iy := len(t.Grid) - 1; iy >= 0; iy-- {
+		if slices.Contains(t.Grid[iy], true) {
+			return t.Y - iy
+		}
 	}
+	return t.Y
 }
 
 /*